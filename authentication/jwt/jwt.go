@@ -40,6 +40,15 @@ func CreateToken(data map[string]interface{}, expires int64) (string, error) {
 
 // ParseToken parses a JWT token and returns the custom data of the token
 func ParseToken(t string) (map[string]interface{}, error) {
+	claims, err := ParseClaims(t)
+	if err != nil {
+		return nil, err
+	}
+	return claims.Data, nil
+}
+
+// ParseClaims parses a JWT token and returns all of its claims
+func ParseClaims(t string) (*Claims, error) {
 	token, err := jwtlib.ParseWithClaims(t, &Claims{}, func(token *jwtlib.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwtlib.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("invalid signing method %v", token.Method)
@@ -54,6 +63,5 @@ func ParseToken(t string) (map[string]interface{}, error) {
 		return nil, fmt.Errorf("invalid token")
 	}
 
-	claims := token.Claims.(*Claims)
-	return claims.Data, nil
+	return token.Claims.(*Claims), nil
 }
@@ -1,11 +1,14 @@
 package jwt
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestJwt(t *testing.T) {
 	expected := map[string]interface{}{"foo": "bar"}
 
-	token, err := CreateToken(expected)
+	token, err := CreateToken(expected, time.Now().Add(time.Hour).Unix())
 	if err != nil {
 		t.Fatalf("expected to pass, but got %v", err)
 	}
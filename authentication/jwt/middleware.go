@@ -0,0 +1,315 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/arjanvaneersel/kit/logger"
+	jwtlib "github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrNoToken is returned by a TokenExtractor when the request doesn't carry a token
+	ErrNoToken = errors.New("no token found")
+
+	// ErrInvalidAuthorizationHeader is returned when the Authorization header isn't a bearer header
+	ErrInvalidAuthorizationHeader = errors.New("authorization header format must be Bearer {token}")
+)
+
+// DefaultRealm is the realm used in WWW-Authenticate headers when the middleware doesn't set one
+var DefaultRealm = "kit"
+
+type contextKey int
+
+const (
+	claimsKey contextKey = iota
+	realmKey
+)
+
+// TokenExtractor extracts a raw JWT token from a request. It returns ErrNoToken when the request
+// doesn't contain a token at the location the extractor looks at.
+type TokenExtractor func(r *http.Request) (string, error)
+
+// FromHeader extracts a token from the "Authorization: Bearer {token}" header
+func FromHeader(r *http.Request) (string, error) {
+	h := r.Header.Get("Authorization")
+	if h == "" {
+		return "", ErrNoToken
+	}
+
+	parts := strings.Fields(h)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return "", ErrInvalidAuthorizationHeader
+	}
+	return parts[1], nil
+}
+
+// FromCookie returns a TokenExtractor which extracts a token from the cookie with the provided name
+func FromCookie(name string) TokenExtractor {
+	return func(r *http.Request) (string, error) {
+		c, err := r.Cookie(name)
+		if err != nil || c.Value == "" {
+			return "", ErrNoToken
+		}
+		return c.Value, nil
+	}
+}
+
+// FromQuery returns a TokenExtractor which extracts a token from the query parameter with the provided name
+func FromQuery(param string) TokenExtractor {
+	return func(r *http.Request) (string, error) {
+		t := r.URL.Query().Get(param)
+		if t == "" {
+			return "", ErrNoToken
+		}
+		return t, nil
+	}
+}
+
+// FirstOf returns a TokenExtractor which tries all provided extractors in order and returns the first token found
+func FirstOf(extractors ...TokenExtractor) TokenExtractor {
+	return func(r *http.Request) (string, error) {
+		for _, e := range extractors {
+			t, err := e(r)
+			if err == ErrNoToken {
+				continue
+			}
+			return t, err
+		}
+		return "", ErrNoToken
+	}
+}
+
+// NewContext returns a copy of ctx which carries the provided claims
+func NewContext(ctx context.Context, c *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, c)
+}
+
+// ClaimsFromContext returns the claims stored in the context by the middleware
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(claimsKey).(*Claims)
+	return c, ok && c != nil
+}
+
+// DataFromContext returns the custom data of the claims stored in the context by the middleware
+func DataFromContext(ctx context.Context) (map[string]interface{}, bool) {
+	c, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return c.Data, true
+}
+
+// SubjectFromContext returns the subject of the claims stored in the context by the middleware.
+// When the standard sub claim isn't set, the "sub" key of the custom data is used.
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	c, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	if c.Subject != "" {
+		return c.Subject, true
+	}
+	s, ok := c.Data["sub"].(string)
+	return s, ok && s != ""
+}
+
+// LogFields is a logger.Extractor which adds the subject of the claims stored in the context as "sub" field:
+//
+//	logger.RegisterExtractor(jwt.LogFields)
+func LogFields(ctx context.Context) []logger.Field {
+	sub, ok := SubjectFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []logger.Field{logger.String("sub", sub)}
+}
+
+// Middleware is net/http middleware which authenticates requests with a JWT token.
+// Valid claims are stored in the request context and can be retrieved with ClaimsFromContext.
+type Middleware struct {
+	// Extractor extracts the token from the request, defaults to FromHeader
+	Extractor TokenExtractor
+
+	// Realm is used in the WWW-Authenticate header, defaults to DefaultRealm
+	Realm string
+
+	// Optional allows requests without a token to pass without claims in the context
+	Optional bool
+}
+
+// NewMiddleware returns a Middleware which extracts tokens with the provided extractors.
+// When no extractors are provided the token is read from the Authorization header.
+func NewMiddleware(extractors ...TokenExtractor) *Middleware {
+	m := Middleware{Extractor: FromHeader}
+	if len(extractors) > 0 {
+		m.Extractor = FirstOf(extractors...)
+	}
+	return &m
+}
+
+// Handler wraps the provided handler and only calls it for requests with a valid token
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		extract := m.Extractor
+		if extract == nil {
+			extract = FromHeader
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), realmKey, m.realm()))
+		t, err := extract(r)
+		if err == ErrNoToken {
+			if m.Optional {
+				next.ServeHTTP(w, r)
+				return
+			}
+			m.unauthorized(w, "", "")
+			return
+		}
+		if err != nil {
+			m.unauthorized(w, "invalid_request", "malformed token")
+			return
+		}
+
+		c, err := ParseClaims(t)
+		if err != nil {
+			m.unauthorized(w, "invalid_token", tokenError(err))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), c)))
+	})
+}
+
+// Middleware is an alias of Handler for routers, which authenticates all routes of a mux.Router:
+//
+//	router.Use(m.Middleware, jwt.RequireScopes("read"))
+func (m *Middleware) Middleware(next http.Handler) http.Handler {
+	return m.Handler(next)
+}
+
+func (m *Middleware) realm() string {
+	if m.Realm != "" {
+		return m.Realm
+	}
+	return DefaultRealm
+}
+
+// realmFromContext returns the realm of the Middleware which handled the request
+func realmFromContext(ctx context.Context) string {
+	if realm, ok := ctx.Value(realmKey).(string); ok {
+		return realm
+	}
+	return DefaultRealm
+}
+
+// tokenError describes why a token was refused without leaking the details of the validation to the client
+func tokenError(err error) string {
+	var ve *jwtlib.ValidationError
+	if errors.As(err, &ve) && ve.Errors&jwtlib.ValidationErrorExpired != 0 {
+		return "token expired"
+	}
+	return "invalid token"
+}
+
+func (m *Middleware) unauthorized(w http.ResponseWriter, code, desc string) {
+	w.Header().Set("WWW-Authenticate", challenge(m.realm(), code, desc, ""))
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// challenge builds a bearer WWW-Authenticate header value as described in RFC 6750
+func challenge(realm, code, desc, scope string) string {
+	s := fmt.Sprintf("Bearer realm=%q", realm)
+	if code != "" {
+		s += fmt.Sprintf(", error=%q", code)
+	}
+	if desc != "" {
+		s += fmt.Sprintf(", error_description=%q", desc)
+	}
+	if scope != "" {
+		s += fmt.Sprintf(", scope=%q", scope)
+	}
+	return s
+}
+
+// ScopeKey is the key in the custom data of the claims which holds the granted scopes.
+// Scopes can either be a space separated string or a list of strings.
+var ScopeKey = "scope"
+
+// RoleKey is the key in the custom data of the claims which holds the granted roles.
+// Roles can either be a space separated string or a list of strings.
+var RoleKey = "roles"
+
+// RequireScopes returns middleware which only allows requests whose claims contain all provided scopes.
+// It must be used behind a Middleware, i.e. router.Use(m.Middleware, jwt.RequireScopes("read")).
+func RequireScopes(scopes ...string) mux.MiddlewareFunc {
+	return require(ScopeKey, true, scopes)
+}
+
+// RequireRoles returns middleware which only allows requests whose claims contain at least one of the provided roles
+func RequireRoles(roles ...string) mux.MiddlewareFunc {
+	return require(RoleKey, false, roles)
+}
+
+func require(key string, all bool, wanted []string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", challenge(realmFromContext(r.Context()), "", "", ""))
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			if !contains(claimValues(c.Data[key]), all, wanted) {
+				scope := ""
+				if key == ScopeKey {
+					scope = strings.Join(wanted, " ")
+				}
+				w.Header().Set("WWW-Authenticate", challenge(realmFromContext(r.Context()), "insufficient_scope", "", scope))
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// claimValues converts a claim holding a space separated string or a list into a set
+func claimValues(v interface{}) map[string]bool {
+	m := make(map[string]bool)
+	switch t := v.(type) {
+	case string:
+		for _, s := range strings.Fields(t) {
+			m[s] = true
+		}
+	case []string:
+		for _, s := range t {
+			m[s] = true
+		}
+	case []interface{}:
+		for _, i := range t {
+			if s, ok := i.(string); ok {
+				m[s] = true
+			}
+		}
+	}
+	return m
+}
+
+func contains(have map[string]bool, all bool, wanted []string) bool {
+	for _, w := range wanted {
+		if have[w] && !all {
+			return true
+		}
+		if !have[w] && all {
+			return false
+		}
+	}
+	return all || len(wanted) == 0
+}
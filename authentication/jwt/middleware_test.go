@@ -0,0 +1,137 @@
+package jwt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	token, err := CreateToken(map[string]interface{}{"sub": "gopher", "scope": "read write"}, time.Now().Add(time.Hour).Unix())
+	if err != nil {
+		t.Fatalf("expected to pass, but got %v", err)
+	}
+
+	var subject string
+	h := NewMiddleware(FromHeader, FromCookie("token"), FromQuery("token")).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject, _ = SubjectFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		req    func() *http.Request
+		status int
+	}{
+		{"no token", func() *http.Request { return httptest.NewRequest("GET", "/", nil) }, http.StatusUnauthorized},
+		{"header", func() *http.Request {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Authorization", "Bearer "+token)
+			return r
+		}, http.StatusOK},
+		{"cookie", func() *http.Request {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.AddCookie(&http.Cookie{Name: "token", Value: token})
+			return r
+		}, http.StatusOK},
+		{"query", func() *http.Request { return httptest.NewRequest("GET", "/?token="+token, nil) }, http.StatusOK},
+		{"invalid", func() *http.Request {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Authorization", "Bearer foo")
+			return r
+		}, http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		subject = ""
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, tc.req())
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, but got %d", tc.name, tc.status, w.Code)
+		}
+		if tc.status == http.StatusOK && subject != "gopher" {
+			t.Errorf("%s: expected subject gopher, but got %q", tc.name, subject)
+		}
+		if tc.status == http.StatusUnauthorized && !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Bearer") {
+			t.Errorf("%s: expected a bearer challenge, but got %q", tc.name, w.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestRequireScopes(t *testing.T) {
+	token, err := CreateToken(map[string]interface{}{"scope": "read", "roles": []string{"admin"}}, time.Now().Add(time.Hour).Unix())
+	if err != nil {
+		t.Fatalf("expected to pass, but got %v", err)
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name   string
+		h      http.Handler
+		status int
+	}{
+		{"scope granted", RequireScopes("read")(ok), http.StatusOK},
+		{"scope missing", RequireScopes("read", "write")(ok), http.StatusForbidden},
+		{"role granted", RequireRoles("user", "admin")(ok), http.StatusOK},
+		{"role missing", RequireRoles("user")(ok), http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		NewMiddleware().Handler(tc.h).ServeHTTP(w, r)
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, but got %d", tc.name, tc.status, w.Code)
+		}
+	}
+}
+
+func TestLogFields(t *testing.T) {
+	if f := LogFields(context.Background()); f != nil {
+		t.Errorf("expected no fields without claims, but got %v", f)
+	}
+
+	ctx := NewContext(context.Background(), &Claims{Data: map[string]interface{}{"sub": "gopher"}})
+	f := LogFields(ctx)
+	if len(f) != 1 || f[0].Key != "sub" || f[0].Str != "gopher" {
+		t.Errorf("expected the subject field, but got %v", f)
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	expired, err := CreateToken(map[string]interface{}{"sub": "gopher"}, time.Now().Add(-time.Hour).Unix())
+	if err != nil {
+		t.Fatalf("expected to pass, but got %v", err)
+	}
+	valid, err := CreateToken(map[string]interface{}{"sub": "gopher"}, time.Now().Add(time.Hour).Unix())
+	if err != nil {
+		t.Fatalf("expected to pass, but got %v", err)
+	}
+
+	mw := NewMiddleware()
+	mw.Realm = "api"
+	h := mw.Handler(RequireScopes("read")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	tests := []struct {
+		name      string
+		token     string
+		challenge string
+	}{
+		{"expired", expired, `Bearer realm="api", error="invalid_token", error_description="token expired"`},
+		{"invalid", "foo", `Bearer realm="api", error="invalid_token", error_description="invalid token"`},
+		{"scope missing", valid, `Bearer realm="api", error="insufficient_scope", scope="read"`},
+	}
+
+	for _, tc := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+tc.token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := w.Header().Get("WWW-Authenticate"); got != tc.challenge {
+			t.Errorf("%s: expected challenge %q, but got %q", tc.name, tc.challenge, got)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+// Command kitsign creates keys and signs, verifies and hashes files with the sign package.
+//
+// Usage:
+//
+//	kitsign keygen [-alg Ed25519] [-bits 2048] [-pass-env VAR | -pass-file FILE] -out key.pem
+//	kitsign hash [-sha512] [FILE]
+//	kitsign sign -key key.pem [-alg RSA-PSS] [-sha512] [-pass-env VAR | -pass-file FILE] [-out FILE.sig] [FILE]
+//	kitsign verify -pub key.pem.pub [-sig FILE.sig] [FILE]
+//	kitsign fingerprint KEY
+//
+// Keys remember the -alg of keygen, so sign only needs -alg to use another algorithm for the key.
+//
+// Files default to stdin when omitted or "-". Signatures of stdin are written to stdout unless -out is given,
+// signatures of files are written to FILE.sig.
+//
+// Exit codes: 0 on success, 1 when a signature is invalid, 2 on usage errors and 3 on any other error.
+package main
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/arjanvaneersel/kit/sign"
+)
+
+// Exit codes
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitUsage   = 2
+	exitError   = 3
+)
+
+// errUsage is returned for invalid command lines, the flag set has already printed the details
+var errUsage = errors.New("usage error")
+
+// errInvalid wraps verification failures
+type errInvalid struct {
+	err error
+}
+
+func (e errInvalid) Error() string {
+	return "invalid signature: " + e.err.Error()
+}
+
+const usage = `usage: kitsign <command> [flags] [args]
+
+commands:
+  keygen       create a key pair
+  hash         print the hash of a file
+  sign         create a detached signature of a file
+  verify       verify a detached signature of a file
+  fingerprint  print the key id and fingerprint of a key
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	commands := map[string]func([]string, io.Reader, io.Writer, io.Writer) error{
+		"keygen":      keygen,
+		"hash":        hash,
+		"sign":        signCmd,
+		"verify":      verify,
+		"fingerprint": fingerprint,
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	err := cmd(args[1:], stdin, stdout, stderr)
+	switch err.(type) {
+	case nil:
+		return exitOK
+	case errInvalid:
+		fmt.Fprintln(stderr, err)
+		return exitInvalid
+	}
+	if err == errUsage {
+		return exitUsage
+	}
+	fmt.Fprintln(stderr, "error:", err)
+	return exitError
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("kitsign "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parse parses the flags and returns the single optional file argument, which defaults to stdin
+func parse(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", errUsage
+	}
+	switch fs.NArg() {
+	case 0:
+		return "-", nil
+	case 1:
+		return fs.Arg(0), nil
+	}
+	fmt.Fprintln(fs.Output(), "too many arguments")
+	return "", errUsage
+}
+
+// open returns stdin for "-" and the file otherwise
+func open(path string, stdin io.Reader) (io.ReadCloser, error) {
+	if path == "-" {
+		return ioutil.NopCloser(stdin), nil
+	}
+	return os.Open(path)
+}
+
+// password reads a password from an environment variable or file
+func password(env, file string) ([]byte, error) {
+	switch {
+	case env != "" && file != "":
+		return nil, errors.New("use either -pass-env or -pass-file")
+	case env != "":
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s isn't set", env)
+		}
+		return []byte(v), nil
+	case file != "":
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.TrimRight(string(b), "\r\n")), nil
+	}
+	return nil, nil
+}
+
+func hashFunc(sha512 bool) crypto.Hash {
+	if sha512 {
+		return crypto.SHA512
+	}
+	return crypto.SHA256
+}
+
+func keygen(args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("keygen", stderr)
+	alg := fs.String("alg", string(sign.Ed25519), "algorithm: RSA-PKCS1v15, RSA-PSS, ECDSA-P256, ECDSA-P384 or Ed25519")
+	bits := fs.Int("bits", sign.DefaultRSAKeySize, "size of RSA keys")
+	out := fs.String("out", "", "file for the private key, the public key is written to FILE.pub")
+	passEnv := fs.String("pass-env", "", "environment variable containing the password to encrypt the key")
+	passFile := fs.String("pass-file", "", "file containing the password to encrypt the key")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *out == "" || fs.NArg() != 0 {
+		fmt.Fprintln(stderr, "-out is required and no arguments are accepted")
+		return errUsage
+	}
+
+	pw, err := password(*passEnv, *passFile)
+	if err != nil {
+		return err
+	}
+
+	var k sign.Signer
+	switch a := sign.Algorithm(*alg); a {
+	case sign.RSAPKCS1v15, sign.RSAPSS:
+		k, err = sign.GenerateRSAKey(a, *bits)
+	default:
+		k, err = sign.GenerateKey(a)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := sign.SavePrivateKey(*out, k, pw); err != nil {
+		return err
+	}
+	if err := sign.SavePublicKey(*out+".pub", k.Public()); err != nil {
+		return err
+	}
+
+	id, err := sign.KeyID(k.Public())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, id)
+	return nil
+}
+
+func hash(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("hash", stderr)
+	sha512 := fs.Bool("sha512", false, "use SHA-512 instead of SHA-256")
+	path, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	r, err := open(path, stdin)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	h, err := sign.HashReader(r, hashFunc(*sha512))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, h)
+	return nil
+}
+
+func signCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("sign", stderr)
+	keyFile := fs.String("key", "", "private key file")
+	alg := fs.String("alg", "", "algorithm, defaults to the algorithm of the key")
+	sha512 := fs.Bool("sha512", false, "use SHA-512 instead of SHA-256")
+	out := fs.String("out", "", "signature file, defaults to FILE.sig or stdout when signing stdin")
+	passEnv := fs.String("pass-env", "", "environment variable containing the password of the key")
+	passFile := fs.String("pass-file", "", "file containing the password of the key")
+	path, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if *keyFile == "" {
+		fmt.Fprintln(stderr, "-key is required")
+		return errUsage
+	}
+
+	pw, err := password(*passEnv, *passFile)
+	if err != nil {
+		return err
+	}
+	k, err := sign.LoadPrivateKey(*keyFile, pw)
+	if err != nil {
+		return err
+	}
+	s, err := sign.NewSigner(k, sign.Algorithm(*alg))
+	if err != nil {
+		return err
+	}
+
+	r, err := open(path, stdin)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	ds, err := sign.SignReader(r, s, hashFunc(*sha512))
+	if err != nil {
+		return err
+	}
+
+	if *out == "" && path != "-" {
+		*out = path + sign.SignatureExt
+	}
+	if *out == "" || *out == "-" {
+		b, err := json.MarshalIndent(ds, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = stdout.Write(append(b, '\n'))
+		return err
+	}
+	return sign.WriteSignatureFile(*out, ds)
+}
+
+func verify(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("verify", stderr)
+	pubFile := fs.String("pub", "", "public key file")
+	sigFile := fs.String("sig", "", "signature file, defaults to FILE.sig")
+	path, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if *pubFile == "" {
+		fmt.Fprintln(stderr, "-pub is required")
+		return errUsage
+	}
+	if *sigFile == "" {
+		if path == "-" {
+			fmt.Fprintln(stderr, "-sig is required when verifying stdin")
+			return errUsage
+		}
+		*sigFile = path + sign.SignatureExt
+	}
+
+	k, err := sign.LoadPublicKey(*pubFile)
+	if err != nil {
+		return err
+	}
+	ds, err := sign.ReadSignatureFile(*sigFile)
+	if err != nil {
+		if invalidSignature(err) {
+			return errInvalid{err}
+		}
+		return err
+	}
+
+	r, err := open(path, stdin)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := sign.VerifyReader(r, ds, k); err != nil {
+		if invalidSignature(err) {
+			return errInvalid{err}
+		}
+		return err
+	}
+	fmt.Fprintln(stdout, "OK", ds.KeyID)
+	return nil
+}
+
+// invalidSignature reports if err is the result of a signature which doesn't match the file or key, as opposed
+// to a failure to check the signature
+func invalidSignature(err error) bool {
+	for _, target := range []error{sign.ErrInvalidSignature, sign.ErrHashMismatch, sign.ErrKeyMismatch, sign.ErrAlgorithmMismatch, rsa.ErrVerification} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func fingerprint(args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("fingerprint", stderr)
+	passEnv := fs.String("pass-env", "", "environment variable containing the password of a private key")
+	passFile := fs.String("pass-file", "", "file containing the password of a private key")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "exactly one key file is required")
+		return errUsage
+	}
+
+	k, err := sign.LoadPublicKey(fs.Arg(0))
+	if err != nil {
+		// The file may contain a private key instead
+		pw, perr := password(*passEnv, *passFile)
+		if perr != nil {
+			return perr
+		}
+		priv, perr := sign.LoadPrivateKey(fs.Arg(0), pw)
+		if perr != nil {
+			return perr
+		}
+		k = priv.Public()
+	}
+
+	id, err := sign.KeyID(k)
+	if err != nil {
+		return err
+	}
+	fp, err := sign.Fingerprint(k)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, id)
+	fmt.Fprintln(stdout, fp)
+	return nil
+}
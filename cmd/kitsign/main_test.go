@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arjanvaneersel/kit/sign"
+)
+
+func kitsign(t *testing.T, stdin string, args ...string) (int, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String() + stderr.String()
+}
+
+func TestSignVerify(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "release.pem")
+	file := filepath.Join(dir, "release.tar.gz")
+	if err := ioutil.WriteFile(file, []byte("release"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("KITSIGN_TEST_PASSWORD", "secret")
+	defer os.Unsetenv("KITSIGN_TEST_PASSWORD")
+
+	tests := []struct {
+		name  string
+		stdin string
+		args  []string
+		code  int
+	}{
+		{"no command", "", nil, exitUsage},
+		{"unknown command", "", []string{"unknown"}, exitUsage},
+		{"keygen without out", "", []string{"keygen"}, exitUsage},
+		{"keygen", "", []string{"keygen", "-alg", "ECDSA-P256", "-pass-env", "KITSIGN_TEST_PASSWORD", "-out", key}, exitOK},
+		{"sign without password", "", []string{"sign", "-key", key, file}, exitError},
+		{"sign", "", []string{"sign", "-key", key, "-pass-env", "KITSIGN_TEST_PASSWORD", file}, exitOK},
+		{"verify", "", []string{"verify", "-pub", key + ".pub", file}, exitOK},
+		{"verify stdin", "release", []string{"verify", "-pub", key + ".pub", "-sig", file + ".sig"}, exitOK},
+		{"verify tampered stdin", "tampered", []string{"verify", "-pub", key + ".pub", "-sig", file + ".sig"}, exitInvalid},
+		{"verify stdin without sig", "release", []string{"verify", "-pub", key + ".pub"}, exitUsage},
+		{"verify missing file", "", []string{"verify", "-pub", key + ".pub", file + ".missing"}, exitError},
+		{"hash", "", []string{"hash", "-sha512", file}, exitOK},
+		{"fingerprint", "", []string{"fingerprint", key + ".pub"}, exitOK},
+		{"fingerprint of private key", "", []string{"fingerprint", "-pass-env", "KITSIGN_TEST_PASSWORD", key}, exitOK},
+	}
+
+	for _, tt := range tests {
+		if code, out := kitsign(t, tt.stdin, tt.args...); code != tt.code {
+			t.Errorf("%s: expected exit code %d, but got %d: %s", tt.name, tt.code, code, out)
+		}
+	}
+
+	if err := ioutil.WriteFile(file, []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := kitsign(t, "", "verify", "-pub", key+".pub", file); code != exitInvalid {
+		t.Errorf("expected a tampered file to exit with %d, but got %d", exitInvalid, code)
+	}
+}
+
+func TestSignStdin(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "key.pem")
+	if code, out := kitsign(t, "", "keygen", "-out", key); code != exitOK {
+		t.Fatalf("expected keygen to pass, but got %d: %s", code, out)
+	}
+
+	code, sig := kitsign(t, "payload", "sign", "-key", key)
+	if code != exitOK {
+		t.Fatalf("expected sign to pass, but got %d: %s", code, sig)
+	}
+	if !strings.Contains(sig, `"algorithm": "Ed25519"`) {
+		t.Errorf("expected an Ed25519 signature on stdout, but got %s", sig)
+	}
+
+	sigFile := filepath.Join(dir, "payload.sig")
+	if err := ioutil.WriteFile(sigFile, []byte(sig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, out := kitsign(t, "payload", "verify", "-pub", key+".pub", "-sig", sigFile, "-"); code != exitOK {
+		t.Errorf("expected verify to pass, but got %d: %s", code, out)
+	}
+
+	_, h1 := kitsign(t, "payload", "hash")
+	_, h2 := kitsign(t, "payload", "hash", "-")
+	if h1 != h2 || !strings.HasPrefix(h1, "sha256:") {
+		t.Errorf("expected equal sha256 hashes, but got %q and %q", h1, h2)
+	}
+}
+
+func TestKeygenAlgorithm(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "key.pem")
+	if code, out := kitsign(t, "", "keygen", "-alg", "RSA-PSS", "-out", key); code != exitOK {
+		t.Fatalf("expected keygen to pass, but got %d: %s", code, out)
+	}
+
+	code, sig := kitsign(t, "payload", "sign", "-key", key)
+	if code != exitOK {
+		t.Fatalf("expected sign to pass, but got %d: %s", code, sig)
+	}
+	if !strings.Contains(sig, `"algorithm": "RSA-PSS"`) {
+		t.Errorf("expected the algorithm of keygen to be used, but got %s", sig)
+	}
+
+	if code, sig = kitsign(t, "payload", "sign", "-key", key, "-alg", "RSA-PKCS1v15"); !strings.Contains(sig, `"algorithm": "RSA-PKCS1v15"`) {
+		t.Errorf("expected -alg to override the algorithm of the key, but got %d: %s", code, sig)
+	}
+}
+
+func TestFingerprintError(t *testing.T) {
+	key := filepath.Join(t.TempDir(), "key.pem")
+	os.Setenv("KITSIGN_TEST_PASSWORD", "secret")
+	defer os.Unsetenv("KITSIGN_TEST_PASSWORD")
+	if code, out := kitsign(t, "", "keygen", "-pass-env", "KITSIGN_TEST_PASSWORD", "-out", key); code != exitOK {
+		t.Fatalf("expected keygen to pass, but got %d: %s", code, out)
+	}
+
+	code, out := kitsign(t, "", "fingerprint", key)
+	if code != exitError || !strings.Contains(out, sign.ErrPasswordRequired.Error()) {
+		t.Errorf("expected the error of the private key, but got %d: %s", code, out)
+	}
+}
+
+func TestVerifyExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "key.pem")
+	other := filepath.Join(dir, "other.pem")
+	sigFile := filepath.Join(dir, "payload.sig")
+	for _, k := range []string{key, other} {
+		if code, out := kitsign(t, "", "keygen", "-out", k); code != exitOK {
+			t.Fatalf("expected keygen to pass, but got %d: %s", code, out)
+		}
+	}
+	if code, out := kitsign(t, "payload", "sign", "-key", key, "-out", sigFile); code != exitOK {
+		t.Fatalf("expected sign to pass, but got %d: %s", code, out)
+	}
+
+	if code, out := kitsign(t, "payload", "verify", "-pub", other+".pub", "-sig", sigFile); code != exitInvalid {
+		t.Errorf("expected another key to exit with %d, but got %d: %s", exitInvalid, code, out)
+	}
+
+	ds, err := sign.ReadSignatureFile(sigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds.Algorithm = string(sign.RSAPSS)
+	if err := sign.WriteSignatureFile(sigFile, ds); err != nil {
+		t.Fatal(err)
+	}
+	if code, out := kitsign(t, "payload", "verify", "-pub", key+".pub", "-sig", sigFile); code != exitInvalid {
+		t.Errorf("expected a tampered algorithm to exit with %d, but got %d: %s", exitInvalid, code, out)
+	}
+
+	// A signature with an unknown hash can't be checked, which isn't the same as an invalid signature
+	ds.Algorithm = string(sign.Ed25519)
+	ds.Hash = "md5:00"
+	if err := sign.WriteSignatureFile(sigFile, ds); err != nil {
+		t.Fatal(err)
+	}
+	if code, out := kitsign(t, "payload", "verify", "-pub", key+".pub", "-sig", sigFile); code != exitError {
+		t.Errorf("expected an unsupported hash to exit with %d, but got %d: %s", exitError, code, out)
+	}
+}
+
+func TestVerifySignFile(t *testing.T) {
+	dir := t.TempDir()
+	pub := filepath.Join(dir, "key.pem.pub")
+	file := filepath.Join(dir, "artifact.bin")
+	if err := ioutil.WriteFile(file, []byte("artifact"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := sign.GenerateKey(sign.ECDSAP384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sign.SavePublicKey(pub, k.Public()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sign.SignFile(file, k, crypto.SHA512); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, out := kitsign(t, "", "verify", "-pub", pub, file); code != exitOK {
+		t.Errorf("expected the signature of SignFile to verify, but got %d: %s", code, out)
+	}
+}
+
+func TestVerifyCorruptSignature(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "key.pem")
+	sigFile := filepath.Join(dir, "payload.sig")
+	if code, out := kitsign(t, "", "keygen", "-out", key); code != exitOK {
+		t.Fatalf("expected keygen to pass, but got %d: %s", code, out)
+	}
+	if code, out := kitsign(t, "payload", "sign", "-key", key, "-out", sigFile); code != exitOK {
+		t.Fatalf("expected sign to pass, but got %d: %s", code, out)
+	}
+
+	ds, err := sign.ReadSignatureFile(sigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds.Signature = "Ed25519:not-hex"
+	if err := sign.WriteSignatureFile(sigFile, ds); err != nil {
+		t.Fatal(err)
+	}
+	if code, out := kitsign(t, "payload", "verify", "-pub", key+".pub", "-sig", sigFile); code != exitInvalid {
+		t.Errorf("expected a corrupt signature to exit with %d, but got %d: %s", exitInvalid, code, out)
+	}
+
+	if err := ioutil.WriteFile(sigFile, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, out := kitsign(t, "payload", "verify", "-pub", key+".pub", "-sig", sigFile); code != exitError {
+		t.Errorf("expected a signature file which isn't JSON to exit with %d, but got %d: %s", exitError, code, out)
+	}
+}
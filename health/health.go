@@ -0,0 +1,287 @@
+// Package health implements a registry of health checks for liveness, readiness and startup probes. Checks run
+// concurrently with a timeout and their results are cached, so frequent probes don't overload dependencies.
+//
+//	r := health.NewRegistry()
+//	r.Register(health.Check{Name: "db", Kind: health.Readiness, Timeout: time.Second, Check: db.PingContext})
+//	router.Handle("/readyz", r.Handler(health.Readiness))
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kind classifies a check, a check can be of multiple kinds, i.e. Liveness|Readiness
+type Kind int
+
+const (
+	// Liveness checks fail when the process has to be restarted
+	Liveness Kind = 1 << iota
+
+	// Readiness checks fail when the process can't serve traffic
+	Readiness
+
+	// Startup checks fail until the process has started. Once all startup checks passed they aren't run again.
+	Startup
+)
+
+// String implements the stringer interface
+func (k Kind) String() string {
+	switch k {
+	case Liveness:
+		return "liveness"
+	case Readiness:
+		return "readiness"
+	case Startup:
+		return "startup"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Statuses of checks and reports
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Defaults of the registry
+const (
+	DefaultTimeout  = 5 * time.Second
+	DefaultCacheTTL = time.Second
+)
+
+var (
+	ErrDuplicateCheck = errors.New("check is already registered")
+	ErrInvalidCheck   = errors.New("check requires a name, kind and function")
+)
+
+// Check is a named health check
+type Check struct {
+	Name string
+	Kind Kind
+
+	// Check returns an error when the component is unhealthy, it should respect the deadline of ctx
+	Check func(ctx context.Context) error
+
+	// Timeout limits the duration of the check, 0 uses the timeout of the registry
+	Timeout time.Duration
+
+	// CacheTTL is the time a result is reused, 0 uses the TTL of the registry and a negative value disables caching
+	CacheTTL time.Duration
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Name      string        `json:"name"`
+	Status    string        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// Report is the outcome of all checks of a kind
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// OK reports if all checks passed
+func (r *Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// entry is a registered check with its cached result
+type entry struct {
+	Check
+	mu     sync.Mutex
+	result *Result
+}
+
+// Registry contains health checks
+type Registry struct {
+	// Timeout is the default timeout of checks
+	Timeout time.Duration
+
+	// CacheTTL is the default time a result is reused
+	CacheTTL time.Duration
+
+	mu      sync.RWMutex
+	checks  []*entry
+	started bool
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{Timeout: DefaultTimeout, CacheTTL: DefaultCacheTTL}
+}
+
+// Register adds a check to the registry
+func (r *Registry) Register(c Check) error {
+	if c.Name == "" || c.Kind == 0 || c.Check == nil {
+		return ErrInvalidCheck
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.checks {
+		if e.Name == c.Name {
+			return fmt.Errorf("%w: %s", ErrDuplicateCheck, c.Name)
+		}
+	}
+	r.checks = append(r.checks, &entry{Check: c})
+	return nil
+}
+
+// Unregister removes the check with the name
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, e := range r.checks {
+		if e.Name == name {
+			r.checks = append(r.checks[:i], r.checks[i+1:]...)
+			return
+		}
+	}
+}
+
+// Run runs all checks of kind concurrently and returns the report. Startup checks only run until they passed once.
+func (r *Registry) Run(ctx context.Context, kind Kind) *Report {
+	r.mu.RLock()
+	var checks []*entry
+	for _, e := range r.checks {
+		if e.Kind&kind != 0 {
+			checks = append(checks, e)
+		}
+	}
+	started := r.started
+	r.mu.RUnlock()
+
+	rep := Report{Status: StatusOK, Checks: make([]Result, len(checks))}
+	if kind == Startup && started {
+		for i, e := range checks {
+			rep.Checks[i] = Result{Name: e.Name, Status: StatusOK}
+		}
+		return &rep
+	}
+
+	var wg sync.WaitGroup
+	for i, e := range checks {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			rep.Checks[i] = r.run(ctx, e)
+		}(i, e)
+	}
+	wg.Wait()
+
+	for _, res := range rep.Checks {
+		if res.Status != StatusOK {
+			rep.Status = StatusFail
+		}
+	}
+	sort.Slice(rep.Checks, func(i, j int) bool { return rep.Checks[i].Name < rep.Checks[j].Name })
+
+	if kind == Startup && rep.OK() {
+		r.mu.Lock()
+		r.started = true
+		r.mu.Unlock()
+	}
+	return &rep
+}
+
+// run runs a single check, or returns its cached result. Concurrent probes wait for the same run.
+func (r *Registry) run(ctx context.Context, e *entry) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ttl := e.CacheTTL
+	if ttl == 0 {
+		ttl = r.CacheTTL
+	}
+	if e.result != nil && ttl > 0 && time.Since(e.result.CheckedAt) < ttl {
+		return *e.result
+	}
+
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = r.Timeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	// The result is shared with other probes, so a cancelled probe mustn't fail the check
+	ctx, cancel := context.WithTimeout(detached{ctx}, timeout)
+	defer cancel()
+
+	start := time.Now()
+	c := make(chan error, 1)
+	go func() {
+		c <- e.Check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-c:
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+			err = fmt.Errorf("timeout after %v", timeout)
+		}
+	case <-ctx.Done():
+		err = fmt.Errorf("timeout after %v", timeout)
+	}
+
+	res := Result{Name: e.Name, Status: StatusOK, Duration: time.Since(start), CheckedAt: start}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	e.result = &res
+	return res
+}
+
+// detached keeps the values of a context without its deadline and cancellation
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
+
+// Handler returns an http.Handler which runs the checks of kind. It responds with 200 when all checks pass
+// and 503 otherwise. With the verbose query parameter the report is returned as JSON.
+func (r *Registry) Handler(kind Kind) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rep := r.Run(req.Context(), kind)
+		WriteReport(w, req, rep)
+	})
+}
+
+// WriteReport writes the report as the response to a probe
+func WriteReport(w http.ResponseWriter, req *http.Request, rep *Report) {
+	code := http.StatusOK
+	if !rep.OK() {
+		code = http.StatusServiceUnavailable
+	}
+
+	if _, ok := req.URL.Query()["verbose"]; ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(rep)
+		return
+	}
+
+	if code != http.StatusOK {
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+	w.WriteHeader(code)
+}
@@ -0,0 +1,175 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+	ok := func(context.Context) error { return nil }
+
+	if err := r.Register(Check{Name: "db", Kind: Readiness, Check: ok}); err != nil {
+		t.Fatalf("expected Register to pass, but got %v", err)
+	}
+	if err := r.Register(Check{Name: "db", Kind: Liveness, Check: ok}); !errors.Is(err, ErrDuplicateCheck) {
+		t.Errorf("expected ErrDuplicateCheck, but got %v", err)
+	}
+	if err := r.Register(Check{Name: "cache", Check: ok}); err != ErrInvalidCheck {
+		t.Errorf("expected ErrInvalidCheck, but got %v", err)
+	}
+
+	r.Unregister("db")
+	if rep := r.Run(context.Background(), Readiness); len(rep.Checks) != 0 || !rep.OK() {
+		t.Errorf("expected an empty passing report, but got %+v", rep)
+	}
+}
+
+func TestRun(t *testing.T) {
+	r := NewRegistry()
+	sleep := func(ctx context.Context) error {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	r.Register(Check{Name: "db", Kind: Readiness, Check: sleep})
+	r.Register(Check{Name: "cache", Kind: Readiness | Liveness, Check: sleep})
+	r.Register(Check{Name: "api", Kind: Readiness, Check: sleep, Timeout: 10 * time.Millisecond})
+
+	start := time.Now()
+	rep := r.Run(context.Background(), Readiness)
+	if d := time.Since(start); d > 90*time.Millisecond {
+		t.Errorf("expected the checks to run concurrently, but it took %v", d)
+	}
+	if rep.OK() || len(rep.Checks) != 3 {
+		t.Fatalf("expected a failing report with 3 checks, but got %+v", rep)
+	}
+	if c := rep.Checks[0]; c.Name != "api" || c.Status != StatusFail || c.Error == "" {
+		t.Errorf("expected api to time out, but got %+v", c)
+	}
+	if c := rep.Checks[2]; c.Name != "db" || c.Status != StatusOK {
+		t.Errorf("expected db to pass, but got %+v", c)
+	}
+
+	if rep := r.Run(context.Background(), Liveness); !rep.OK() || len(rep.Checks) != 1 {
+		t.Errorf("expected only the cache liveness check, but got %+v", rep)
+	}
+}
+
+func TestCache(t *testing.T) {
+	r := NewRegistry()
+	r.CacheTTL = 50 * time.Millisecond
+
+	var calls, uncached int32
+	r.Register(Check{Name: "db", Kind: Readiness, Check: func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}})
+	r.Register(Check{Name: "flag", Kind: Readiness, CacheTTL: -1, Check: func(context.Context) error {
+		atomic.AddInt32(&uncached, 1)
+		return nil
+	}})
+
+	for i := 0; i < 3; i++ {
+		r.Run(context.Background(), Readiness)
+	}
+	if calls != 1 || uncached != 3 {
+		t.Errorf("expected 1 cached and 3 uncached calls, but got %d and %d", calls, uncached)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	r.Run(context.Background(), Readiness)
+	if calls != 2 {
+		t.Errorf("expected the expired result to be refreshed, but got %d calls", calls)
+	}
+}
+
+func TestStartup(t *testing.T) {
+	r := NewRegistry()
+	var started, calls int32
+	r.Register(Check{Name: "migrations", Kind: Startup, CacheTTL: -1, Check: func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&started) == 0 {
+			return errors.New("running")
+		}
+		return nil
+	}})
+
+	if r.Run(context.Background(), Startup).OK() {
+		t.Errorf("expected startup to fail")
+	}
+	atomic.StoreInt32(&started, 1)
+	if !r.Run(context.Background(), Startup).OK() {
+		t.Errorf("expected startup to pass")
+	}
+
+	atomic.StoreInt32(&started, 0)
+	if !r.Run(context.Background(), Startup).OK() || calls != 2 {
+		t.Errorf("expected startup to stay passed without running the checks, but got %d calls", calls)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: "db", Kind: Readiness, Check: func(context.Context) error {
+		return errors.New("connection refused")
+	}})
+
+	w := httptest.NewRecorder()
+	r.Handler(Liveness).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 without liveness checks, but got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.Handler(Readiness).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz?verbose", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, but got %d", w.Code)
+	}
+
+	var rep Report
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+		t.Fatalf("expected a JSON report, but got %v: %s", err, w.Body.String())
+	}
+	if rep.Status != StatusFail || len(rep.Checks) != 1 || rep.Checks[0].Error != "connection refused" {
+		t.Errorf("unexpected report %+v", rep)
+	}
+}
+
+func TestRunCancelledProbe(t *testing.T) {
+	r := NewRegistry()
+	type key struct{}
+	r.Register(Check{Name: "db", Kind: Readiness, Timeout: time.Second, Check: func(ctx context.Context) error {
+		if ctx.Value(key{}) == nil {
+			return errors.New("expected the values of the probe")
+		}
+		select {
+		case <-time.After(20 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}})
+	r.Register(Check{Name: "cache", Kind: Readiness, Check: func(context.Context) error {
+		return context.DeadlineExceeded
+	}})
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, true))
+	cancel()
+	rep := r.Run(ctx, Readiness)
+	if c := rep.Checks[1]; c.Name != "db" || c.Status != StatusOK {
+		t.Errorf("expected db to pass for a cancelled probe, but got %+v", c)
+	}
+	if c := rep.Checks[0]; c.Status != StatusFail || c.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected the error of the check instead of a timeout, but got %+v", c)
+	}
+}
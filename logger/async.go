@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy determines what an Async writer does when its buffer is full
+type OverflowPolicy int
+
+const (
+	// Block waits until there is room in the buffer, so no lines are lost but logging can slow down the caller
+	Block OverflowPolicy = iota
+
+	// Drop discards lines which don't fit in the buffer, they're counted by Dropped
+	Drop
+)
+
+// String implements the stringer interface
+func (p OverflowPolicy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case Drop:
+		return "drop"
+	}
+	return "unknown"
+}
+
+// DefaultAsyncBuffer is the number of lines an Async writer buffers by default
+const DefaultAsyncBuffer = 1024
+
+// asyncLine is a buffered line, or a flush request when flushed isn't nil
+type asyncLine struct {
+	b       *[]byte
+	flushed chan error
+}
+
+var asyncPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 512)
+		return &b
+	},
+}
+
+// Async is an io.WriteCloser which buffers lines and writes them to the underlying writer in the background, so
+// slow writers don't block logging. Lines which are written after Close are passed to the underlying writer
+// synchronously, which works for writers that reopen, like RotatingFile.
+type Async struct {
+	dropped uint64 // first for 64-bit alignment of atomic operations
+
+	w      io.Writer
+	policy OverflowPolicy
+	lines  chan asyncLine
+	done   chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+	wmu    sync.Mutex
+}
+
+// NewAsync returns an Async writer which buffers up to size lines for w, a size of 0 uses DefaultAsyncBuffer
+func NewAsync(w io.Writer, size int, policy OverflowPolicy) *Async {
+	if size <= 0 {
+		size = DefaultAsyncBuffer
+	}
+	a := &Async{
+		w:      w,
+		policy: policy,
+		lines:  make(chan asyncLine, size),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *Async) run() {
+	defer close(a.done)
+	for l := range a.lines {
+		if l.flushed != nil {
+			l.flushed <- a.flush()
+			continue
+		}
+		a.write(*l.b)
+		if cap(*l.b) <= maxPooledBuffer {
+			asyncPool.Put(l.b)
+		}
+	}
+}
+
+// write writes to the underlying writer
+func (a *Async) write(p []byte) (int, error) {
+	a.wmu.Lock()
+	defer a.wmu.Unlock()
+	return a.w.Write(p)
+}
+
+// flush flushes the underlying writer when it implements Flusher
+func (a *Async) flush() error {
+	f, ok := a.w.(Flusher)
+	if !ok {
+		return nil
+	}
+	a.wmu.Lock()
+	defer a.wmu.Unlock()
+	return f.Flush()
+}
+
+// Write implements io.Writer, it copies p into the buffer and returns without waiting for the underlying writer
+func (a *Async) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return a.write(p)
+	}
+
+	bp := asyncPool.Get().(*[]byte)
+	*bp = append((*bp)[:0], p...)
+	l := asyncLine{b: bp}
+	if a.policy == Drop {
+		select {
+		case a.lines <- l:
+		default:
+			atomic.AddUint64(&a.dropped, 1)
+			asyncPool.Put(bp)
+		}
+		return len(p), nil
+	}
+
+	a.lines <- l
+	return len(p), nil
+}
+
+// Dropped returns the number of lines which were discarded because the buffer was full
+func (a *Async) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Flush waits until the buffered lines are written and flushes the underlying writer
+func (a *Async) Flush() error {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return a.flush()
+	}
+
+	c := make(chan error, 1)
+	a.lines <- asyncLine{flushed: c}
+	a.mu.RUnlock()
+	return <-c
+}
+
+// Close writes the buffered lines and flushes and closes the underlying writer, except os.Stdout and os.Stderr
+func (a *Async) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.lines)
+	a.mu.Unlock()
+
+	<-a.done
+	err := a.flush()
+	if cerr := closeWriter(a.w); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// slowWriter blocks writes until it's released
+type slowWriter struct {
+	closeBuffer
+	mu      sync.Mutex
+	release chan struct{}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closeBuffer.Write(p)
+}
+
+func (w *slowWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closeBuffer.String()
+}
+
+func TestAsyncBlock(t *testing.T) {
+	w := &slowWriter{release: make(chan struct{})}
+	close(w.release)
+	a := NewAsync(w, 2, Block)
+
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(a, "line %d\n", i)
+	}
+	if err := a.Flush(); err != nil {
+		t.Fatalf("expected Flush to pass, but got %v", err)
+	}
+	if n := strings.Count(w.String(), "\n"); n != 100 || w.flushed != 1 {
+		t.Errorf("expected 100 lines and a flush, but got %d lines and %d flushes", n, w.flushed)
+	}
+
+	a.Close()
+	a.Write([]byte("after close\n"))
+	if !strings.HasSuffix(w.String(), "after close\n") || w.closed != 1 {
+		t.Errorf("expected a synchronous write after close, but got %q", w.String())
+	}
+}
+
+func TestAsyncDrop(t *testing.T) {
+	w := &slowWriter{release: make(chan struct{})}
+	a := NewAsync(w, 2, Drop)
+
+	for i := 0; i < 10; i++ {
+		if n, err := fmt.Fprintf(a, "line %d\n", i); err != nil || n != 7 {
+			t.Fatalf("expected Write not to block or fail, but got %d, %v", n, err)
+		}
+	}
+	close(w.release)
+	a.Close()
+
+	written := strings.Count(w.String(), "\n")
+	if dropped := a.Dropped(); dropped == 0 || uint64(written)+dropped != 10 {
+		t.Errorf("expected the dropped and written lines to add up to 10, but got %d and %d", dropped, written)
+	}
+	if !strings.HasPrefix(w.String(), "line 0\n") {
+		t.Errorf("expected the first lines to be written, but got %q", w.String())
+	}
+}
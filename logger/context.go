@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	requestIDKey
+	scopeKey
+)
+
+// Default is the logger returned by FromContext when the context doesn't carry a logger
+var Default = NewStructured(os.Stderr, INFO, JSONEncoder{})
+
+// Extractor returns the log fields found in a context, i.e. a trace id or the subject of a token
+type Extractor func(ctx context.Context) []Field
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []Extractor
+)
+
+// RegisterExtractor adds extractors which FromContext applies to every logger it returns. Extractors are
+// typically registered during initialization.
+func RegisterExtractor(e ...Extractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, e...)
+}
+
+// WithContext returns a copy of ctx which carries l
+func WithContext(ctx context.Context, l *Structured) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext returns the logger stored in ctx, or Default, with the request scoped fields added with AddFields
+// and the fields of the registered extractors
+func FromContext(ctx context.Context) *Structured {
+	l, ok := ctx.Value(loggerKey).(*Structured)
+	if !ok || l == nil {
+		l = Default
+	}
+
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
+}
+
+// ContextFields returns the request scoped fields and the fields of the registered extractors
+func ContextFields(ctx context.Context) []Field {
+	var fields []Field
+	if s, ok := ctx.Value(scopeKey).(*scope); ok {
+		s.mu.Lock()
+		fields = append(fields, s.fields...)
+		s.mu.Unlock()
+	}
+
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	for _, e := range extractors {
+		fields = append(fields, e(ctx)...)
+	}
+	return fields
+}
+
+// scope holds the fields which are added during a request. It's shared by all contexts derived from the
+// request context, so fields added by inner middleware are also part of the access log.
+type scope struct {
+	mu     sync.Mutex
+	fields []Field
+}
+
+// withScope returns a copy of ctx with an empty scope
+func withScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey, &scope{})
+}
+
+// AddFields adds fields to the scope of the request, they're added to the loggers returned by FromContext and to
+// the access log line. It's a no-op when ctx wasn't created by the AccessLog middleware.
+func AddFields(ctx context.Context, fields ...Field) {
+	s, ok := ctx.Value(scopeKey).(*scope)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	s.fields = append(s.fields, fields...)
+	s.mu.Unlock()
+}
+
+// RequestIDFromContext returns the request id stored in the context by the RequestID middleware
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok && id != ""
+}
@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type traceKey struct{}
+
+func TestFromContext(t *testing.T) {
+	if l := FromContext(context.Background()); l != Default {
+		t.Errorf("expected the default logger")
+	}
+
+	RegisterExtractor(func(ctx context.Context) []Field {
+		if id, ok := ctx.Value(traceKey{}).(string); ok {
+			return []Field{String("trace_id", id)}
+		}
+		return nil
+	})
+
+	l, b := newTestStructured(INFO, LogfmtEncoder{})
+	ctx := WithContext(context.Background(), l)
+	if FromContext(ctx) != l {
+		t.Errorf("expected the stored logger without extracted fields")
+	}
+
+	FromContext(context.WithValue(ctx, traceKey{}, "t1")).Info("traced")
+	if !strings.Contains(b.String(), "msg=traced trace_id=t1") {
+		t.Errorf("expected the extracted field, but got %s", b.String())
+	}
+}
+
+func TestAddFieldsWithoutScope(t *testing.T) {
+	ctx := context.Background()
+	AddFields(ctx, String("ignored", "yes"))
+	if f := ContextFields(ctx); len(f) != 0 {
+		t.Errorf("expected no fields, but got %v", f)
+	}
+}
@@ -0,0 +1,320 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Entry is a single log line
+type Entry struct {
+	Time    time.Time
+	Level   LogLevel
+	Message string
+
+	// Caller is the file and line of the caller, it's empty unless the logger records callers
+	Caller string
+	Fields []Field
+}
+
+// Encoder appends an encoded entry, including a trailing newline, to dst. Encoders are used concurrently.
+type Encoder interface {
+	Encode(dst []byte, e *Entry) []byte
+}
+
+// JSONEncoder encodes entries as a JSON object per line:
+//
+//	{"time":"2021-03-04T10:11:12.123456789Z","level":"INFO","msg":"started","addr":":8080"}
+type JSONEncoder struct{}
+
+// Encode implements the Encoder interface
+func (JSONEncoder) Encode(dst []byte, e *Entry) []byte {
+	dst = append(dst, `{"time":"`...)
+	dst = e.Time.AppendFormat(dst, time.RFC3339Nano)
+	dst = append(dst, `","level":"`...)
+	dst = append(dst, e.Level.String()...)
+	dst = append(dst, '"')
+	if e.Message != "" {
+		dst = append(dst, `,"msg":`...)
+		dst = appendJSONString(dst, e.Message)
+	}
+	if e.Caller != "" {
+		dst = append(dst, `,"caller":`...)
+		dst = appendJSONString(dst, e.Caller)
+	}
+	for i := range e.Fields {
+		f := &e.Fields[i]
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, f.Key)
+		dst = append(dst, ':')
+		dst = appendJSONValue(dst, f)
+	}
+	return append(dst, '}', '\n')
+}
+
+func appendJSONValue(dst []byte, f *Field) []byte {
+	switch f.Type {
+	case StringType:
+		return appendJSONString(dst, f.Str)
+	case IntType:
+		return strconv.AppendInt(dst, f.Int, 10)
+	case UintType:
+		return strconv.AppendUint(dst, uint64(f.Int), 10)
+	case FloatType:
+		v := math.Float64frombits(uint64(f.Int))
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			// JSON has no representation for these values
+			return strconv.AppendQuote(dst, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		return strconv.AppendFloat(dst, v, 'g', -1, 64)
+	case BoolType:
+		return strconv.AppendBool(dst, f.Int != 0)
+	case DurationType:
+		return appendJSONString(dst, time.Duration(f.Int).String())
+	case TimeType:
+		dst = append(dst, '"')
+		dst = f.time().AppendFormat(dst, time.RFC3339Nano)
+		return append(dst, '"')
+	case ErrorType:
+		if f.Iface == nil {
+			return append(dst, "null"...)
+		}
+		return appendJSONString(dst, f.Iface.(error).Error())
+	case StringerType:
+		return appendJSONString(dst, f.Iface.(fmt.Stringer).String())
+	}
+
+	b, err := json.Marshal(f.Iface)
+	if err != nil {
+		return appendJSONString(dst, fmt.Sprint(f.Iface))
+	}
+	return append(dst, b...)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a quoted JSON string, invalid UTF-8 is replaced by U+FFFD
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				dst = append(dst, '\\', c)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// LogfmtEncoder encodes entries as logfmt key=value pairs:
+//
+//	time=2021-03-04T10:11:12.123456789Z level=INFO msg="server started" addr=:8080
+type LogfmtEncoder struct{}
+
+// Encode implements the Encoder interface
+func (LogfmtEncoder) Encode(dst []byte, e *Entry) []byte {
+	dst = append(dst, "time="...)
+	dst = e.Time.AppendFormat(dst, time.RFC3339Nano)
+	dst = append(dst, " level="...)
+	dst = append(dst, e.Level.String()...)
+	if e.Message != "" {
+		dst = append(dst, " msg="...)
+		dst = appendLogfmtValue(dst, e.Message)
+	}
+	if e.Caller != "" {
+		dst = append(dst, " caller="...)
+		dst = appendLogfmtValue(dst, e.Caller)
+	}
+	for i := range e.Fields {
+		dst = append(dst, ' ')
+		dst = appendLogfmtField(dst, &e.Fields[i])
+	}
+	return append(dst, '\n')
+}
+
+func appendLogfmtField(dst []byte, f *Field) []byte {
+	dst = appendLogfmtKey(dst, f.Key)
+	dst = append(dst, '=')
+	return appendLogfmtFieldValue(dst, f)
+}
+
+func appendLogfmtFieldValue(dst []byte, f *Field) []byte {
+	switch f.Type {
+	case StringType:
+		return appendLogfmtValue(dst, f.Str)
+	case IntType:
+		return strconv.AppendInt(dst, f.Int, 10)
+	case UintType:
+		return strconv.AppendUint(dst, uint64(f.Int), 10)
+	case FloatType:
+		return strconv.AppendFloat(dst, math.Float64frombits(uint64(f.Int)), 'g', -1, 64)
+	case BoolType:
+		return strconv.AppendBool(dst, f.Int != 0)
+	case DurationType:
+		return append(dst, time.Duration(f.Int).String()...)
+	case TimeType:
+		return f.time().AppendFormat(dst, time.RFC3339Nano)
+	case ErrorType:
+		if f.Iface == nil {
+			return append(dst, "null"...)
+		}
+		return appendLogfmtValue(dst, f.Iface.(error).Error())
+	case StringerType:
+		return appendLogfmtValue(dst, f.Iface.(fmt.Stringer).String())
+	}
+	return appendLogfmtValue(dst, fmt.Sprint(f.Iface))
+}
+
+// appendLogfmtKey appends the key with characters which aren't allowed in logfmt keys replaced by underscores
+func appendLogfmtKey(dst []byte, key string) []byte {
+	if key == "" {
+		return append(dst, '_')
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c <= ' ' || c == '=' || c == '"' || c >= utf8.RuneSelf {
+			c = '_'
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+// appendLogfmtValue appends the value, quoted when it's empty or contains spaces, quotes, equal signs or
+// control characters
+func appendLogfmtValue(dst []byte, v string) []byte {
+	if v == "" {
+		return append(dst, '"', '"')
+	}
+	for i := 0; i < len(v); i++ {
+		if c := v[i]; c <= ' ' || c == '=' || c == '"' || c == '\\' || c == 0x7f {
+			return appendJSONString(dst, v)
+		}
+	}
+	if !utf8.ValidString(v) {
+		return appendJSONString(dst, v)
+	}
+	return append(dst, v...)
+}
+
+// ANSI colours of the console encoder
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorGray   = "\x1b[90m"
+)
+
+// ConsoleEncoder encodes entries for humans:
+//
+//	2021-03-04T10:11:12.123456789Z INFO  server started addr=:8080
+type ConsoleEncoder struct {
+	// Color adds ANSI colours to the level and keys
+	Color bool
+}
+
+// Encode implements the Encoder interface
+func (c ConsoleEncoder) Encode(dst []byte, e *Entry) []byte {
+	dst = e.Time.AppendFormat(dst, time.RFC3339Nano)
+	dst = append(dst, ' ')
+
+	lvl := e.Level.String()
+	if c.Color {
+		dst = append(dst, levelColor(e.Level)...)
+	}
+	dst = append(dst, lvl...)
+	if c.Color {
+		dst = append(dst, colorReset...)
+	}
+	for i := len(lvl); i < 5; i++ {
+		dst = append(dst, ' ')
+	}
+
+	if e.Message != "" {
+		dst = append(dst, ' ')
+		dst = appendConsoleMessage(dst, e.Message)
+	}
+	for i := range e.Fields {
+		dst = append(dst, ' ')
+		if c.Color {
+			dst = append(dst, colorGray...)
+			dst = appendLogfmtKey(dst, e.Fields[i].Key)
+			dst = append(dst, '=')
+			dst = append(dst, colorReset...)
+			dst = appendLogfmtFieldValue(dst, &e.Fields[i])
+			continue
+		}
+		dst = appendLogfmtField(dst, &e.Fields[i])
+	}
+	if e.Caller != "" {
+		dst = append(dst, ' ')
+		if c.Color {
+			dst = append(dst, colorGray...)
+		}
+		dst = append(dst, e.Caller...)
+		if c.Color {
+			dst = append(dst, colorReset...)
+		}
+	}
+	return append(dst, '\n')
+}
+
+// appendConsoleMessage appends the message as is, unless it contains control characters or invalid UTF-8. Those
+// messages are quoted, so they can't forge lines or terminal escapes.
+func appendConsoleMessage(dst []byte, msg string) []byte {
+	for i := 0; i < len(msg); i++ {
+		if c := msg[i]; c < ' ' || c == 0x7f {
+			return appendJSONString(dst, msg)
+		}
+	}
+	if !utf8.ValidString(msg) {
+		return appendJSONString(dst, msg)
+	}
+	return append(dst, msg...)
+}
+
+func levelColor(lvl LogLevel) string {
+	switch lvl {
+	case FATAL, ERROR:
+		return colorRed
+	case WARN:
+		return colorYellow
+	case INFO:
+		return colorBlue
+	}
+	return colorGray
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testTime = time.Date(2021, 3, 4, 10, 11, 12, 123456789, time.UTC)
+
+func testEntry() *Entry {
+	return &Entry{
+		Time:    testTime,
+		Level:   INFO,
+		Message: "server started",
+		Fields: []Field{
+			String("addr", ":8080"),
+			Int("port", 8080),
+			Uint64("conns", 3),
+			Float64("load", 0.5),
+			Bool("tls", true),
+			Duration("timeout", 1500*time.Millisecond),
+			Err(errors.New(`dial "db": refused`)),
+			Any("tags", []string{"a", "b"}),
+		},
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	got := string(JSONEncoder{}.Encode(nil, testEntry()))
+	expected := `{"time":"2021-03-04T10:11:12.123456789Z","level":"INFO","msg":"server started","addr":":8080",` +
+		`"port":8080,"conns":3,"load":0.5,"tls":true,"timeout":"1.5s","error":"dial \"db\": refused","tags":["a","b"]}` + "\n"
+	if got != expected {
+		t.Errorf("expected %s, but got %s", expected, got)
+	}
+}
+
+func TestJSONEscaping(t *testing.T) {
+	e := &Entry{Time: testTime, Level: WARN, Message: "line\nbreak\t\x01 \\ \xff ünïcode", Fields: []Field{
+		Float64("inf", math.Inf(1)),
+		NamedErr("cause", nil),
+		Time("at", time.Date(2021, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))),
+	}}
+	b := JSONEncoder{}.Encode(nil, e)
+
+	var v map[string]interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("expected valid JSON, but got %v: %s", err, b)
+	}
+	if msg := v["msg"]; msg != "line\nbreak\t\x01 \\ � ünïcode" {
+		t.Errorf("unexpected message %q", msg)
+	}
+	if v["inf"] != "+Inf" || v["cause"] != nil || v["at"] != "2021-01-02T03:04:05+01:00" {
+		t.Errorf("unexpected fields %v", v)
+	}
+}
+
+func TestTimeRange(t *testing.T) {
+	e := &Entry{Time: testTime, Level: INFO, Fields: []Field{
+		Time("zero", time.Time{}),
+		Time("future", time.Date(3000, 1, 2, 3, 4, 5, 6, time.UTC)),
+	}}
+
+	got := string(JSONEncoder{}.Encode(nil, e))
+	if expected := `"zero":"0001-01-01T00:00:00Z","future":"3000-01-02T03:04:05.000000006Z"`; !strings.Contains(got, expected) {
+		t.Errorf("expected %s, but got %s", expected, got)
+	}
+	got = string(LogfmtEncoder{}.Encode(nil, e))
+	if expected := `zero=0001-01-01T00:00:00Z future=3000-01-02T03:04:05.000000006Z`; !strings.Contains(got, expected) {
+		t.Errorf("expected %s, but got %s", expected, got)
+	}
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	got := string(LogfmtEncoder{}.Encode(nil, testEntry()))
+	expected := `time=2021-03-04T10:11:12.123456789Z level=INFO msg="server started" addr=:8080 port=8080 conns=3 ` +
+		`load=0.5 tls=true timeout=1.5s error="dial \"db\": refused" tags="[a b]"` + "\n"
+	if got != expected {
+		t.Errorf("expected %s, but got %s", expected, got)
+	}
+
+	e := &Entry{Time: testTime, Level: DEBUG, Fields: []Field{String("giving up on", ""), String("k", "a=b")}}
+	got = string(LogfmtEncoder{}.Encode(nil, e))
+	if expected := `time=2021-03-04T10:11:12.123456789Z level=DEBUG giving_up_on="" k="a=b"` + "\n"; got != expected {
+		t.Errorf("expected %s, but got %s", expected, got)
+	}
+}
+
+func TestConsoleEncoder(t *testing.T) {
+	e := &Entry{Time: testTime, Level: WARN, Message: "slow", Caller: "api/handler.go:12", Fields: []Field{Int("ms", 900)}}
+
+	got := string(ConsoleEncoder{}.Encode(nil, e))
+	if expected := "2021-03-04T10:11:12.123456789Z WARN  slow ms=900 api/handler.go:12\n"; got != expected {
+		t.Errorf("expected %q, but got %q", expected, got)
+	}
+
+	got = string(ConsoleEncoder{Color: true}.Encode(nil, e))
+	if !strings.Contains(got, colorYellow+"WARN"+colorReset) || !strings.Contains(got, colorGray+"ms="+colorReset+"900") {
+		t.Errorf("expected coloured output, but got %q", got)
+	}
+
+	e = &Entry{Time: testTime, Level: INFO, Message: "forged\n2021-03-04T10:11:12Z ERROR \x1b[31mboom"}
+	got = string(ConsoleEncoder{}.Encode(nil, e))
+	if expected := `2021-03-04T10:11:12.123456789Z INFO  "forged\n2021-03-04T10:11:12Z ERROR \u001b[31mboom"` + "\n"; got != expected {
+		t.Errorf("expected a quoted message, but got %q", got)
+	}
+}
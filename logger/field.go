@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// FieldType determines how the value of a field is stored and encoded
+type FieldType uint8
+
+const (
+	AnyType FieldType = iota
+	StringType
+	IntType
+	UintType
+	FloatType
+	BoolType
+	DurationType
+	TimeType
+	ErrorType
+	StringerType
+)
+
+// Field is a typed key/value pair. Numbers, booleans and durations are stored without allocating, times,
+// errors and arbitrary values are kept as interfaces.
+type Field struct {
+	Key   string
+	Type  FieldType
+	Int   int64
+	Str   string
+	Iface interface{}
+}
+
+// String returns a string field
+func String(key, v string) Field {
+	return Field{Key: key, Type: StringType, Str: v}
+}
+
+// Int returns an integer field
+func Int(key string, v int) Field {
+	return Field{Key: key, Type: IntType, Int: int64(v)}
+}
+
+// Int64 returns an integer field
+func Int64(key string, v int64) Field {
+	return Field{Key: key, Type: IntType, Int: v}
+}
+
+// Uint64 returns an unsigned integer field
+func Uint64(key string, v uint64) Field {
+	return Field{Key: key, Type: UintType, Int: int64(v)}
+}
+
+// Float64 returns a floating point field
+func Float64(key string, v float64) Field {
+	return Field{Key: key, Type: FloatType, Int: int64(math.Float64bits(v))}
+}
+
+// Bool returns a boolean field
+func Bool(key string, v bool) Field {
+	var i int64
+	if v {
+		i = 1
+	}
+	return Field{Key: key, Type: BoolType, Int: i}
+}
+
+// Duration returns a duration field
+func Duration(key string, v time.Duration) Field {
+	return Field{Key: key, Type: DurationType, Int: int64(v)}
+}
+
+// Time returns a time field, which is encoded in RFC3339Nano
+func Time(key string, v time.Time) Field {
+	return Field{Key: key, Type: TimeType, Iface: v}
+}
+
+// Err returns an error field with the key "error". A nil error is encoded as null.
+func Err(err error) Field {
+	return NamedErr("error", err)
+}
+
+// NamedErr returns an error field
+func NamedErr(key string, err error) Field {
+	return Field{Key: key, Type: ErrorType, Iface: err}
+}
+
+// Stringer returns a field which value is the result of v.String(), which is only called when the entry is written
+func Stringer(key string, v fmt.Stringer) Field {
+	return Field{Key: key, Type: StringerType, Iface: v}
+}
+
+// Any returns a typed field for known types and an AnyType field otherwise. AnyType fields are encoded with
+// encoding/json by the JSON encoder and with fmt by the other encoders.
+func Any(key string, v interface{}) Field {
+	switch v := v.(type) {
+	case Field:
+		return v
+	case string:
+		return String(key, v)
+	case int:
+		return Int(key, v)
+	case int8:
+		return Int64(key, int64(v))
+	case int16:
+		return Int64(key, int64(v))
+	case int32:
+		return Int64(key, int64(v))
+	case int64:
+		return Int64(key, v)
+	case uint:
+		return Uint64(key, uint64(v))
+	case uint8:
+		return Uint64(key, uint64(v))
+	case uint16:
+		return Uint64(key, uint64(v))
+	case uint32:
+		return Uint64(key, uint64(v))
+	case uint64:
+		return Uint64(key, v)
+	case float32:
+		return Float64(key, float64(v))
+	case float64:
+		return Float64(key, v)
+	case bool:
+		return Bool(key, v)
+	case time.Duration:
+		return Duration(key, v)
+	case time.Time:
+		return Time(key, v)
+	case error:
+		return NamedErr(key, v)
+	case fmt.Stringer:
+		return Stringer(key, v)
+	}
+	return Field{Key: key, Type: AnyType, Iface: v}
+}
+
+// time returns the value of a TimeType field
+func (f Field) time() time.Time {
+	t, _ := f.Iface.(time.Time)
+	return t
+}
+
+// fieldsFromArgs converts the arguments of Logger.Log to fields. Fields are used as is, other arguments are
+// key/value pairs and a trailing single argument is returned as the message.
+func fieldsFromArgs(dst []Field, args []interface{}) ([]Field, string) {
+	var msg string
+	for i := 0; i < len(args); i++ {
+		if f, ok := args[i].(Field); ok {
+			dst = append(dst, f)
+			continue
+		}
+		if i == len(args)-1 {
+			msg = fmt.Sprint(args[i])
+			break
+		}
+		key, ok := args[i].(string)
+		if !ok {
+			key = fmt.Sprint(args[i])
+		}
+		dst = append(dst, Any(key, args[i+1]))
+		i++
+	}
+	return dst, msg
+}
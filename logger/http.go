@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RequestIDHeader is the header which carries the request id
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the length of request ids which are accepted from clients
+const maxRequestIDLength = 128
+
+// RequestID is http middleware which propagates the X-Request-ID header of a request, or generates an id when the
+// header is missing or invalid. The id is set on the response and stored in the request context, where it can be
+// retrieved with RequestIDFromContext. It's compatible with mux.Router.Use.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+			r.Header.Set(RequestIDHeader, id)
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+// validRequestID accepts ids of printable ASCII characters, so clients can't inject anything into logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// Fall back to a time based id, which is unique enough to correlate log lines
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// AccessLog returns http middleware which stores l, with the request id when there is one, in the request context
+// and writes an access line for every request. Lines of requests which failed with a 5xx status are written as
+// ERROR, others as INFO.
+//
+// Extractors are applied to the context of the AccessLog middleware, so middleware which adds values to the
+// context, i.e. authentication, has to wrap AccessLog for its fields to appear in the access line. Fields added
+// with AddFields always appear.
+//
+//	r.Use(logger.RequestID, logger.AccessLog(l), auth)
+func AccessLog(l *Structured) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			rl := l
+			if id, ok := RequestIDFromContext(r.Context()); ok {
+				rl = l.With(String("request_id", id))
+			}
+			ctx := withScope(WithContext(r.Context(), rl))
+
+			aw := accessWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(&aw, r.WithContext(ctx))
+
+			lvl := INFO
+			if aw.status >= http.StatusInternalServerError {
+				lvl = ERROR
+			}
+			if !rl.Enabled(lvl) {
+				return
+			}
+			FromContext(ctx).log(lvl, "request", []Field{
+				String("method", r.Method),
+				String("path", r.URL.Path),
+				Int("status", aw.status),
+				Int64("bytes", aw.bytes),
+				Duration("duration", time.Since(start)),
+				String("remote", r.RemoteAddr),
+			})
+		})
+	}
+}
+
+// accessWriter captures the status code and size of a response
+type accessWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *accessWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher when the underlying writer does
+func (w *accessWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the underlying writer does, so websockets keep working
+func (w *accessWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking isn't supported")
+	}
+	w.wroteHeader = true
+	w.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Push implements http.Pusher when the underlying writer does
+func (w *accessWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// ReadFrom implements io.ReaderFrom, so the underlying writer can use sendfile
+func (w *accessWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.wroteHeader = true
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(w.ResponseWriter, r)
+	}
+	w.bytes += n
+	return n, err
+}
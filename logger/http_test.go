@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = RequestIDFromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if seen != "abc-123" || w.Header().Get(RequestIDHeader) != "abc-123" {
+		t.Errorf("expected the id to be propagated, but got %q and %q", seen, w.Header().Get(RequestIDHeader))
+	}
+
+	for _, id := range []string{"", "bad\nid", strings.Repeat("x", maxRequestIDLength+1)} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(RequestIDHeader, id)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if len(seen) != 32 || seen == id || w.Header().Get(RequestIDHeader) != seen {
+			t.Errorf("expected a generated id for %q, but got %q", id, seen)
+		}
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	l, b := newTestStructured(INFO, JSONEncoder{})
+
+	h := RequestID(AccessLog(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddFields(r.Context(), String("user", "gopher"))
+		FromContext(r.Context()).Info("handling")
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})))
+
+	r := httptest.NewRequest(http.MethodPost, "/users?id=1", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a handler and an access line, but got %q", b.String())
+	}
+
+	var handler, access map[string]interface{}
+	json.Unmarshal([]byte(lines[0]), &handler)
+	json.Unmarshal([]byte(lines[1]), &access)
+
+	if handler["request_id"] != "req-1" || handler["user"] != "gopher" {
+		t.Errorf("expected the request scoped fields in the handler line, but got %v", handler)
+	}
+	for k, v := range map[string]interface{}{
+		"level": "ERROR", "msg": "request", "request_id": "req-1", "user": "gopher",
+		"method": "POST", "path": "/users", "status": float64(500), "bytes": float64(5),
+	} {
+		if access[k] != v {
+			t.Errorf("expected %s to be %v, but got %v", k, v, access[k])
+		}
+	}
+	if _, ok := access["duration"]; !ok {
+		t.Errorf("expected a duration, but got %v", access)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"runtime"
 	"strings"
+	"sync/atomic"
 )
 
 type LogLevel int
@@ -60,19 +61,45 @@ type Logger interface {
 	Log(LogLevel, ...interface{})
 }
 
-// StdLog turns a standard logger into a Logger interface implementation
+// Leveled is implemented by loggers which level can be changed at runtime
+type Leveled interface {
+	GetLevel() LogLevel
+	SetLevel(LogLevel)
+}
+
+// StdLog turns a standard logger into a Logger interface implementation. Loggers created with NewStdLog keep
+// their level behind a pointer, so copies share it and it can be changed while logging. Loggers which are
+// created as a literal use Level and write to the standard logger of the log package.
 type StdLog struct {
 	logger      *log.Logger
+	level       *int32
 	Level       LogLevel
 	ExitOnFatal bool
 }
 
+// GetLevel returns the level of the logger
+func (l StdLog) GetLevel() LogLevel {
+	if l.level == nil {
+		return l.Level
+	}
+	return LogLevel(atomic.LoadInt32(l.level))
+}
+
+// SetLevel changes the level of the logger. It's safe to use while logging with loggers created by NewStdLog.
+func (l *StdLog) SetLevel(lvl LogLevel) {
+	if l.level == nil {
+		l.Level = lvl
+		return
+	}
+	atomic.StoreInt32(l.level, int32(lvl))
+}
+
 // Log will output a log line with the caller function, line and the arguments
 // Arguments are primarily considered in key/value pairs, i.e. Log("key1", "value1", "key2", "value2")
 // If there is no 2nd value argument given, the single argument will be used, i.e. Log("Single argument") or
 // Log("key1", "value1", "single argument")
 func (l StdLog) Log(lvl LogLevel, args ...interface{}) {
-	if lvl > l.Level {
+	if lvl > l.GetLevel() {
 		return
 	}
 
@@ -103,13 +130,18 @@ func (l StdLog) Log(lvl LogLevel, args ...interface{}) {
 
 	// Print the constructed log line
 	txt := buffer.String()
+	logger := l.logger
+	if logger == nil {
+		logger = log.Default()
+	}
 	if (lvl <= FATAL) && l.ExitOnFatal {
-		l.logger.Fatalf(txt)
+		logger.Fatal(txt)
 	} else {
-		l.logger.Printf(txt)
+		logger.Print(txt)
 	}
 }
 
 func NewStdLog(lvl LogLevel, logger *log.Logger) *StdLog {
-	return &StdLog{Level: lvl, logger: logger, ExitOnFatal: true}
+	level := int32(lvl)
+	return &StdLog{level: &level, Level: lvl, logger: logger, ExitOnFatal: true}
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"os"
 	"strings"
 	"testing"
 )
@@ -98,3 +99,48 @@ func TestString(t *testing.T) {
 	}
 
 }
+
+func TestPercentInValue(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := NewStdLog(DEBUG, log.New(&buffer, "", 0))
+
+	logger.Log(DEBUG, "progress", "100%d done")
+	if expected := "progress: 100%d done"; !strings.Contains(buffer.String(), expected) {
+		t.Errorf("expected %v, but got %v", expected, buffer.String())
+	}
+}
+
+func TestSharedLevel(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := NewStdLog(INFO, log.New(&buffer, "", 0))
+
+	var copied Logger = *logger
+	logger.SetLevel(DEBUG)
+	copied.Log(DEBUG, "foo")
+	if expected := "foo"; !strings.Contains(buffer.String(), expected) {
+		t.Errorf("expected the copy to use the new level, but got %v", buffer.String())
+	}
+}
+
+func TestZeroStdLog(t *testing.T) {
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+	defer log.SetOutput(os.Stderr)
+
+	var l StdLog
+	if l.GetLevel() != FATAL {
+		t.Errorf("expected FATAL, but got %v", l.GetLevel())
+	}
+	l.Log(DEBUG, "hidden")
+	l.SetLevel(DEBUG)
+	l.Log(DEBUG, "visible")
+
+	lit := StdLog{Level: WARN}
+	lit.Log(INFO, "hidden")
+	lit.Log(WARN, "warning")
+
+	got := buffer.String()
+	if strings.Contains(got, "hidden") || !strings.Contains(got, "visible") || !strings.Contains(got, "warning") {
+		t.Errorf("expected only the entries of the level, but got %q", got)
+	}
+}
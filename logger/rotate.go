@@ -0,0 +1,302 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp in the names of rotated files
+const backupTimeFormat = "20060102T150405.000"
+
+// RotatingFile is an io.WriteCloser which writes to a file and rotates it when it reaches a size or when the time
+// interval changes. Rotated files are renamed to name-<timestamp>.ext, optionally gzipped, and removed after the
+// retention. The file is (re)opened on the first write, so it's safe to write after Close.
+//
+//	f := logger.NewRotatingFile("/var/log/app.log")
+//	f.MaxSize = 100 << 20
+//	f.Interval = 24 * time.Hour
+//	f.MaxBackups = 7
+//	f.Compress = true
+type RotatingFile struct {
+	// Filename is the path of the current file
+	Filename string
+
+	// MaxSize rotates the file before a write would make it larger than MaxSize bytes, 0 disables it
+	MaxSize int64
+
+	// Interval rotates the file when the current time is in another interval than the time the file was opened,
+	// intervals are aligned to UTC, i.e. 24 * time.Hour rotates at midnight UTC. 0 disables it.
+	Interval time.Duration
+
+	// MaxBackups is the number of rotated files which are kept, 0 keeps all
+	MaxBackups int
+
+	// MaxAge removes rotated files which are older, 0 keeps them regardless of their age
+	MaxAge time.Duration
+
+	// Compress gzips rotated files
+	Compress bool
+
+	// Mode is the permission of created files, 0644 by default
+	Mode os.FileMode
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+	now    func() time.Time
+
+	millMu sync.Mutex
+	mills  sync.WaitGroup
+}
+
+// NewRotatingFile returns a rotating file which writes to filename
+func NewRotatingFile(filename string) *RotatingFile {
+	return &RotatingFile{Filename: filename, Mode: 0644, now: time.Now}
+}
+
+// Write implements io.Writer
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.due(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// due reports if the file has to be rotated before writing n bytes, the caller must hold the lock
+func (r *RotatingFile) due(n int64) bool {
+	if r.MaxSize > 0 && r.size > 0 && r.size+n > r.MaxSize {
+		return true
+	}
+	if r.Interval > 0 && !r.clock().Truncate(r.Interval).Equal(r.opened.Truncate(r.Interval)) {
+		return true
+	}
+	return false
+}
+
+// open opens or creates the file, the caller must hold the lock
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.Filename), 0755); err != nil {
+		return err
+	}
+	mode := r.Mode
+	if mode == 0 {
+		mode = 0644
+	}
+
+	f, err := os.OpenFile(r.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, mode)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	r.f, r.size, r.opened = f, info.Size(), r.clock()
+	if info.Size() > 0 {
+		// Continue the interval in which the existing file was last written
+		r.opened = info.ModTime()
+	}
+	return nil
+}
+
+// rotate renames the current file, opens a new one and compresses and removes backups in the background. The
+// caller must hold the lock.
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+
+	now := r.clock()
+	backup := r.backupName(now)
+	if err := os.Rename(r.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.mills.Add(1)
+	go func() {
+		defer r.mills.Done()
+		r.mill(backup, now)
+	}()
+	return nil
+}
+
+// Rotate rotates the file immediately, i.e. on SIGHUP
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	return r.rotate()
+}
+
+// Flush commits the written data to stable storage
+func (r *RotatingFile) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Sync()
+}
+
+// Close closes the file and waits for the compression and removal of backups
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	if r.f != nil {
+		err = r.f.Close()
+		r.f = nil
+	}
+	r.mu.Unlock()
+
+	r.mills.Wait()
+	return err
+}
+
+func (r *RotatingFile) clock() time.Time {
+	if r.now == nil {
+		return time.Now()
+	}
+	return r.now()
+}
+
+// backupName returns name-<timestamp>.ext for the file rotated at t, the timestamp is increased until the name
+// isn't used by an existing backup
+func (r *RotatingFile) backupName(t time.Time) string {
+	prefix, ext := r.backupParts()
+	for {
+		name := prefix + t.UTC().Format(backupTimeFormat) + ext
+		if !exists(name) && !exists(name+".gz") {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// backupParts returns the prefix and extension of backup names
+func (r *RotatingFile) backupParts() (string, string) {
+	ext := filepath.Ext(r.Filename)
+	return strings.TrimSuffix(r.Filename, ext) + "-", ext
+}
+
+// mill compresses the file which was rotated at now and removes backups beyond the retention. Mills run one at
+// a time.
+func (r *RotatingFile) mill(rotated string, now time.Time) {
+	r.millMu.Lock()
+	defer r.millMu.Unlock()
+
+	if r.Compress {
+		if err := compressFile(rotated); err != nil {
+			// Keep the uncompressed backup, it's removed by the retention like any other backup
+			os.Remove(rotated + ".gz")
+		}
+	}
+
+	if r.MaxBackups <= 0 && r.MaxAge <= 0 {
+		return
+	}
+
+	type backup struct {
+		path string
+		t    time.Time
+	}
+	prefix, ext := r.backupParts()
+	dir, prefix := filepath.Dir(prefix), filepath.Base(prefix)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(name, prefix)
+		ts = strings.TrimSuffix(ts, ".gz")
+		ts = strings.TrimSuffix(ts, ext)
+		t, err := time.Parse(backupTimeFormat, ts)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), t: t})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].t.After(backups[j].t) })
+
+	cutoff := now.Add(-r.MaxAge)
+	for n, b := range backups {
+		if (r.MaxBackups > 0 && n >= r.MaxBackups) || (r.MaxAge > 0 && b.t.Before(cutoff)) {
+			os.Remove(b.path)
+		}
+	}
+}
+
+// compressFile gzips the file to path.gz and removes the original
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func backups(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("expected ReadDir to pass, but got %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Name() != "app.log" {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotateSize(t *testing.T) {
+	dir := t.TempDir()
+	f := NewRotatingFile(filepath.Join(dir, "app.log"))
+	f.MaxSize = 10
+	f.MaxBackups = 2
+
+	now := testTime
+	f.now = func() time.Time { return now }
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		f.Write([]byte(line))
+		now = now.Add(time.Second)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("expected Close to pass, but got %v", err)
+	}
+
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "app.log"))
+	if string(b) != "fourth\n" {
+		t.Errorf("expected the last line in the current file, but got %q", b)
+	}
+
+	names := backups(t, dir)
+	expected := []string{"app-20210304T101114.123.log", "app-20210304T101115.123.log"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected the 2 newest backups %v, but got %v", expected, names)
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(dir, names[1])); string(b) != "third\n" {
+		t.Errorf("expected the third line in the newest backup, but got %q", b)
+	}
+}
+
+func TestRotateInterval(t *testing.T) {
+	dir := t.TempDir()
+	f := NewRotatingFile(filepath.Join(dir, "app.log"))
+	f.Interval = 24 * time.Hour
+	f.Compress = true
+	f.MaxAge = 12 * time.Hour
+
+	now := testTime
+	f.now = func() time.Time { return now }
+	for _, day := range []string{"day 1\n", "day 2\n", "day 3\n"} {
+		f.Write([]byte(day))
+		now = now.Add(24 * time.Hour)
+	}
+	f.Close()
+
+	names := backups(t, dir)
+	if len(names) != 1 || names[0] != "app-20210306T101112.123.log.gz" {
+		t.Fatalf("expected only the compressed backup of day 2 within the max age, but got %v", names)
+	}
+
+	r, err := os.Open(filepath.Join(dir, names[0]))
+	if err != nil {
+		t.Fatalf("expected Open to pass, but got %v", err)
+	}
+	defer r.Close()
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatalf("expected a gzip file, but got %v", err)
+	}
+	if b, _ := ioutil.ReadAll(gz); string(b) != "day 2\n" {
+		t.Errorf("expected day 2 in the backup, but got %q", b)
+	}
+}
+
+func TestRotateAfterClose(t *testing.T) {
+	dir := t.TempDir()
+	f := NewRotatingFile(filepath.Join(dir, "logs", "app.log"))
+	f.Write([]byte("before\n"))
+	f.Close()
+	if _, err := f.Write([]byte("after\n")); err != nil {
+		t.Fatalf("expected the file to be reopened, but got %v", err)
+	}
+	if err := f.Rotate(); err != nil {
+		t.Fatalf("expected Rotate to pass, but got %v", err)
+	}
+	f.Close()
+
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "logs", "app.log"))
+	if len(b) != 0 {
+		t.Errorf("expected an empty file after rotating, but got %q", b)
+	}
+	if names := backups(t, filepath.Join(dir, "logs")); len(names) != 1 {
+		t.Errorf("expected 1 backup, but got %v", names)
+	}
+}
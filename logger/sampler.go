@@ -0,0 +1,288 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// rateLimit is a token bucket which limits the entries of a level
+type rateLimit struct {
+	perSecond float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+}
+
+// allow takes a token when there is one
+func (r *rateLimit) allow(now time.Time) bool {
+	r.tokens += now.Sub(r.last).Seconds() * r.perSecond
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// SamplerStats contains the number of entries a Sampler dropped per level
+type SamplerStats struct {
+	// Sampled are the entries which were dropped by sampling
+	Sampled map[LogLevel]uint64
+
+	// RateLimited are the entries which exceeded the rate limit of their level
+	RateLimited map[LogLevel]uint64
+
+	// Repeated are the identical entries which were collapsed into a "repeated N times" entry
+	Repeated map[LogLevel]uint64
+}
+
+// Dropped returns the total number of dropped entries
+func (s SamplerStats) Dropped() uint64 {
+	var n uint64
+	for _, m := range []map[LogLevel]uint64{s.Sampled, s.RateLimited, s.Repeated} {
+		for _, v := range m {
+			n += v
+		}
+	}
+	return n
+}
+
+// Sampler is a Logger which limits the entries written to the next logger. Per message and interval the first
+// entries are written, after that every Thereafter-th entry. Levels can be rate limited and consecutive identical
+// entries can be collapsed. FATAL entries are always written.
+//
+// The message of an entry is the trailing single argument of Logger.Log, like Structured uses it. Entries without
+// a message are sampled by their first key. Entries of levels the next logger doesn't write, according to its Enabled or GetLevel method, are
+// discarded before sampling. Options have to be set before the sampler is used.
+//
+//	s := logger.NewSampler(l, 100, 10, time.Second)
+//	s.Dedup = true
+//	s.SetRateLimit(logger.DEBUG, 50, 100)
+type Sampler struct {
+	// First is the number of entries per message and interval which are written
+	First int
+
+	// Thereafter writes every Thereafter-th entry of a message after First, 0 drops all of them
+	Thereafter int
+
+	// Interval is the period after which the counts of messages reset
+	Interval time.Duration
+
+	// Dedup collapses consecutive identical entries. They're reported as a single "repeated N times" entry when
+	// another entry is logged, when the sampler is flushed or at least once per Interval.
+	Dedup bool
+
+	next Logger
+	now  func() time.Time
+
+	mu          sync.Mutex
+	counts      map[string]int
+	windowStart time.Time
+	limits      map[LogLevel]*rateLimit
+
+	hasLast      bool
+	last         string
+	lastLevel    LogLevel
+	repeated     int
+	repeatedFrom time.Time
+
+	sampled, rateLimited, dedup map[LogLevel]uint64
+}
+
+// NewSampler returns a sampler which writes the first entries of a message per interval to next, and every
+// thereafter-th entry after that
+func NewSampler(next Logger, first, thereafter int, interval time.Duration) *Sampler {
+	return &Sampler{
+		First:       first,
+		Thereafter:  thereafter,
+		Interval:    interval,
+		next:        next,
+		now:         time.Now,
+		counts:      make(map[string]int),
+		limits:      make(map[LogLevel]*rateLimit),
+		sampled:     make(map[LogLevel]uint64),
+		rateLimited: make(map[LogLevel]uint64),
+		dedup:       make(map[LogLevel]uint64),
+	}
+}
+
+// SetRateLimit limits the entries of lvl to perSecond, with bursts of up to burst entries. A perSecond of 0
+// removes the limit.
+func (s *Sampler) SetRateLimit(lvl LogLevel, perSecond float64, burst int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if perSecond <= 0 {
+		delete(s.limits, lvl)
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	s.limits[lvl] = &rateLimit{perSecond: perSecond, burst: float64(burst), tokens: float64(burst), last: s.now()}
+}
+
+// Log implements the Logger interface
+func (s *Sampler) Log(lvl LogLevel, args ...interface{}) {
+	if !s.enabled(lvl) {
+		// Entries which the next logger discards don't count towards the samples and limits
+		return
+	}
+	if lvl <= FATAL {
+		s.flushRepeated()
+		s.next.Log(lvl, args...)
+		return
+	}
+
+	now := s.now()
+	var line string
+	if s.Dedup {
+		line = fmt.Sprintln(args...)
+	}
+
+	s.mu.Lock()
+	if s.Dedup && s.hasLast && lvl == s.lastLevel && line == s.last {
+		s.repeated++
+		s.dedup[lvl]++
+		var n int
+		if s.Interval > 0 && now.Sub(s.repeatedFrom) >= s.Interval {
+			// Report long running repetitions once per interval
+			n, s.repeated, s.repeatedFrom = s.repeated, 0, now
+		}
+		s.mu.Unlock()
+		s.logRepeated(lvl, n)
+		return
+	}
+
+	n, nlvl := s.repeated, s.lastLevel
+	s.repeated, s.hasLast = 0, false
+	ok := s.allow(now, lvl, args)
+	if s.Dedup && ok {
+		s.hasLast, s.last, s.lastLevel, s.repeatedFrom = true, line, lvl, now
+	}
+	s.mu.Unlock()
+
+	s.logRepeated(nlvl, n)
+	if ok {
+		s.next.Log(lvl, args...)
+	}
+}
+
+// enabled reports if the next logger writes entries of lvl, loggers without Enabled or a level write all entries
+func (s *Sampler) enabled(lvl LogLevel) bool {
+	switch l := s.next.(type) {
+	case interface{ Enabled(LogLevel) bool }:
+		return l.Enabled(lvl)
+	case Leveled:
+		return lvl <= l.GetLevel()
+	}
+	return true
+}
+
+func (s *Sampler) logRepeated(lvl LogLevel, n int) {
+	if n > 0 {
+		s.next.Log(lvl, fmt.Sprintf("last entry repeated %d times", n))
+	}
+}
+
+// flushRepeated writes the pending "repeated N times" entry
+func (s *Sampler) flushRepeated() {
+	s.mu.Lock()
+	n, lvl := s.repeated, s.lastLevel
+	s.repeated, s.hasLast = 0, false
+	s.mu.Unlock()
+	s.logRepeated(lvl, n)
+}
+
+// allow applies the sampling and rate limit of the level, the caller must hold the lock
+func (s *Sampler) allow(now time.Time, lvl LogLevel, args []interface{}) bool {
+	if s.First > 0 || s.Thereafter > 0 {
+		key := sampleKey(lvl, args)
+		_, seen := s.counts[key]
+		if (s.Interval > 0 && now.Sub(s.windowStart) >= s.Interval) || (!seen && len(s.counts) >= maxSampleKeys) {
+			// Also start a new window when there are too many messages, so the counts don't grow without bound
+			s.counts = make(map[string]int)
+			s.windowStart = now
+		}
+
+		n := s.counts[key] + 1
+		s.counts[key] = n
+		if n > s.First && (s.Thereafter <= 0 || (n-s.First)%s.Thereafter != 0) {
+			s.sampled[lvl]++
+			return false
+		}
+	}
+
+	if l, ok := s.limits[lvl]; ok && !l.allow(now) {
+		s.rateLimited[lvl]++
+		return false
+	}
+	return true
+}
+
+// maxSampleKeys is the number of messages which are counted per interval
+const maxSampleKeys = 10000
+
+// sampleKey returns the level and message of an entry, or its first key when it has no message. The arguments
+// are interpreted like fieldsFromArgs does.
+func sampleKey(lvl LogLevel, args []interface{}) string {
+	var first string
+	for i := 0; i < len(args); i++ {
+		if f, ok := args[i].(Field); ok {
+			if i == 0 {
+				first = f.Key
+			}
+			continue
+		}
+		if i == len(args)-1 {
+			return lvl.String() + " " + argString(args[i])
+		}
+		if i == 0 {
+			first = argString(args[i])
+		}
+		i++
+	}
+	return lvl.String() + " " + first
+}
+
+func argString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// Stats returns the number of dropped entries
+func (s *Sampler) Stats() SamplerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := SamplerStats{
+		Sampled:     make(map[LogLevel]uint64, len(s.sampled)),
+		RateLimited: make(map[LogLevel]uint64, len(s.rateLimited)),
+		Repeated:    make(map[LogLevel]uint64, len(s.dedup)),
+	}
+	for k, v := range s.sampled {
+		st.Sampled[k] = v
+	}
+	for k, v := range s.rateLimited {
+		st.RateLimited[k] = v
+	}
+	for k, v := range s.dedup {
+		st.Repeated[k] = v
+	}
+	return st
+}
+
+// Flush writes the pending "repeated N times" entry and flushes the next logger when it implements Flusher
+func (s *Sampler) Flush() error {
+	s.flushRepeated()
+	if f, ok := s.next.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package logger
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordLogger records the entries it receives
+type recordLogger struct {
+	mu      sync.Mutex
+	lines   []string
+	flushed int
+}
+
+func (l *recordLogger) Log(lvl LogLevel, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, strings.TrimSpace(lvl.String()+" "+fmt.Sprintln(args...)))
+}
+
+func (l *recordLogger) Flush() error {
+	l.flushed++
+	return nil
+}
+
+func newTestSampler(first, thereafter int) (*Sampler, *recordLogger, *time.Time) {
+	r := &recordLogger{}
+	s := NewSampler(r, first, thereafter, time.Second)
+	now := testTime
+	s.now = func() time.Time { return now }
+	return s, r, &now
+}
+
+func TestSampling(t *testing.T) {
+	s, r, now := newTestSampler(2, 3)
+
+	for i := 1; i <= 10; i++ {
+		s.Log(ERROR, "n", i, "query failed")
+	}
+	s.Log(ERROR, "other message")
+	s.Log(WARN, "n", 0, "query failed")
+
+	expected := []string{
+		"ERROR n 1 query failed", "ERROR n 2 query failed", "ERROR n 5 query failed", "ERROR n 8 query failed",
+		"ERROR other message", "WARN n 0 query failed",
+	}
+	if fmt.Sprint(r.lines) != fmt.Sprint(expected) {
+		t.Errorf("expected %q, but got %q", expected, r.lines)
+	}
+	if st := s.Stats(); st.Sampled[ERROR] != 6 || st.Dropped() != 6 {
+		t.Errorf("expected 6 sampled entries, but got %+v", st)
+	}
+
+	*now = now.Add(time.Second)
+	s.Log(ERROR, "n", 11, "query failed")
+	if last := r.lines[len(r.lines)-1]; last != "ERROR n 11 query failed" {
+		t.Errorf("expected the counts to reset after the interval, but got %q", last)
+	}
+}
+
+func TestSamplingMessage(t *testing.T) {
+	s, r, _ := newTestSampler(1, 0)
+
+	for i := 0; i < 3; i++ {
+		s.Log(ERROR, "user", i, "db timeout")
+		s.Log(ERROR, "user", i, "cache miss")
+		s.Log(ERROR, String("user", "x"), "queue full")
+	}
+
+	expected := []string{"ERROR user 0 db timeout", "ERROR user 0 cache miss", "ERROR {user 1 0 x <nil>} queue full"}
+	if fmt.Sprint(r.lines) != fmt.Sprint(expected) {
+		t.Errorf("expected %q, but got %q", expected, r.lines)
+	}
+}
+
+func TestSamplingKeys(t *testing.T) {
+	s, _, _ := newTestSampler(1, 0)
+	s.Interval = 0
+
+	for i := 0; i < maxSampleKeys+10; i++ {
+		s.Log(INFO, i)
+	}
+	if n := len(s.counts); n > maxSampleKeys {
+		t.Errorf("expected at most %d counted messages, but got %d", maxSampleKeys, n)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s, r, now := newTestSampler(0, 0)
+	s.SetRateLimit(DEBUG, 2, 3)
+
+	for i := 0; i < 10; i++ {
+		s.Log(DEBUG, "tick", i)
+	}
+	if len(r.lines) != 3 {
+		t.Errorf("expected the burst of 3 entries, but got %q", r.lines)
+	}
+
+	*now = now.Add(time.Second)
+	for i := 0; i < 10; i++ {
+		s.Log(DEBUG, "tick", i)
+		s.Log(INFO, "unlimited", i)
+	}
+	if len(r.lines) != 15 {
+		t.Errorf("expected 2 more debug and 10 info entries, but got %d", len(r.lines))
+	}
+	if st := s.Stats(); st.RateLimited[DEBUG] != 15 || st.RateLimited[INFO] != 0 {
+		t.Errorf("expected 15 rate limited debug entries, but got %+v", st)
+	}
+
+	s.Log(FATAL, "fatal is never limited")
+	s.SetRateLimit(FATAL, 1, 1)
+	s.Log(FATAL, "fatal is never limited")
+	if len(r.lines) != 17 {
+		t.Errorf("expected fatal entries to pass, but got %d", len(r.lines))
+	}
+}
+
+func TestDedup(t *testing.T) {
+	s, r, now := newTestSampler(0, 0)
+	s.Dedup = true
+
+	for i := 0; i < 4; i++ {
+		s.Log(WARN, "disk", "full")
+	}
+	s.Log(WARN, "disk", "ok")
+
+	expected := []string{"WARN disk full", "WARN last entry repeated 3 times", "WARN disk ok"}
+	if fmt.Sprint(r.lines) != fmt.Sprint(expected) {
+		t.Errorf("expected %q, but got %q", expected, r.lines)
+	}
+
+	r.lines = nil
+	s.Log(WARN, "disk", "ok")
+	*now = now.Add(time.Second)
+	s.Log(WARN, "disk", "ok")
+	if expected := []string{"WARN last entry repeated 2 times"}; fmt.Sprint(r.lines) != fmt.Sprint(expected) {
+		t.Errorf("expected a report after the interval, but got %q", r.lines)
+	}
+
+	r.lines = nil
+	s.Log(WARN, "disk", "ok")
+	s.Flush()
+	if expected := []string{"WARN last entry repeated 1 times"}; fmt.Sprint(r.lines) != fmt.Sprint(expected) || r.flushed != 1 {
+		t.Errorf("expected a report and a flush of the next logger, but got %q", r.lines)
+	}
+	if st := s.Stats(); st.Repeated[WARN] != 6 {
+		t.Errorf("expected 6 repeated entries, but got %+v", st)
+	}
+}
+
+func TestSamplerDisabledLevel(t *testing.T) {
+	var b strings.Builder
+	l := NewStdLog(INFO, log.New(&b, "", 0))
+	s := NewSampler(l, 1, 0, time.Minute)
+	s.SetRateLimit(DEBUG, 1, 1)
+
+	for i := 0; i < 5; i++ {
+		s.Log(DEBUG, "cache miss")
+	}
+	if st := s.Stats(); st.Dropped() != 0 {
+		t.Errorf("expected disabled entries not to be counted, but got %+v", st)
+	}
+
+	l.SetLevel(DEBUG)
+	s.Log(DEBUG, "cache miss")
+	if !strings.Contains(b.String(), "cache miss") {
+		t.Errorf("expected the first enabled entry to be written, but got %q", b.String())
+	}
+}
+
+func TestSamplerConcurrency(t *testing.T) {
+	r := &recordLogger{}
+	s := NewSampler(r, 10, 10, time.Minute)
+	s.Dedup = true
+	s.SetRateLimit(INFO, 1000, 1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				s.Log(INFO, "worker", i, "n", n%3)
+			}
+		}(i)
+	}
+	wg.Wait()
+	s.Flush()
+
+	if written, dropped := uint64(len(r.lines)), s.Stats().Dropped(); written == 0 || dropped == 0 {
+		t.Errorf("expected written and dropped entries, but got %d and %d", written, dropped)
+	}
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// Flusher is implemented by writers and loggers which buffer output
+type Flusher interface {
+	Flush() error
+}
+
+// Sink is a destination of a structured logger with its own encoder and level
+type Sink struct {
+	// Level is the least severe level which is written to the sink
+	Level LogLevel
+
+	mu  sync.Mutex
+	w   io.Writer
+	enc Encoder
+}
+
+// NewSink returns a sink which writes entries of lvl and below to w, encoded by enc. A nil encoder uses the
+// JSONEncoder.
+func NewSink(w io.Writer, lvl LogLevel, enc Encoder) *Sink {
+	if enc == nil {
+		enc = JSONEncoder{}
+	}
+	return &Sink{Level: lvl, w: w, enc: enc}
+}
+
+// write encodes the entry into buf, writes it and returns buf for reuse
+func (s *Sink) write(buf []byte, e *Entry) []byte {
+	buf = s.enc.Encode(buf, e)
+	s.mu.Lock()
+	s.w.Write(buf)
+	s.mu.Unlock()
+	return buf
+}
+
+// Flush flushes the writer when it implements Flusher
+func (s *Sink) Flush() error {
+	f, ok := s.w.(Flusher)
+	if !ok {
+		return nil
+	}
+	return f.Flush()
+}
+
+// Close flushes the writer and closes it when it implements io.Closer. os.Stdout and os.Stderr aren't closed.
+func (s *Sink) Close() error {
+	err := s.Flush()
+	if cerr := closeWriter(s.w); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// closeWriter closes w when it's an io.Closer other than os.Stdout and os.Stderr
+func closeWriter(w io.Writer) error {
+	if w == os.Stdout || w == os.Stderr {
+		return nil
+	}
+	if c, ok := w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// closeBuffer records flushes and closes
+type closeBuffer struct {
+	bytes.Buffer
+	flushed, closed int
+}
+
+func (b *closeBuffer) Flush() error {
+	b.flushed++
+	return nil
+}
+
+func (b *closeBuffer) Close() error {
+	b.closed++
+	return nil
+}
+
+func TestFanout(t *testing.T) {
+	var console, file closeBuffer
+	l := NewStructuredSinks(DEBUG,
+		NewSink(&console, WARN, ConsoleEncoder{}),
+		NewSink(&file, DEBUG, JSONEncoder{}),
+	)
+	l.now = func() time.Time { return testTime }
+
+	l.Debug("details", Int("n", 1))
+	l.Error("failed")
+
+	if expected := "2021-03-04T10:11:12.123456789Z ERROR failed\n"; console.String() != expected {
+		t.Errorf("expected only the error on the console, but got %q", console.String())
+	}
+	if lines := strings.Count(file.String(), "\n"); lines != 2 || !strings.Contains(file.String(), `"msg":"details","n":1`) {
+		t.Errorf("expected both entries as JSON in the file, but got %q", file.String())
+	}
+
+	l.SetLevel(INFO)
+	l.Debug("hidden")
+	if strings.Contains(file.String(), "hidden") {
+		t.Errorf("expected the level of the logger to apply to all sinks")
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("expected Close to pass, but got %v", err)
+	}
+	if console.flushed != 1 || console.closed != 1 || file.flushed != 1 || file.closed != 1 {
+		t.Errorf("expected all sinks to be flushed and closed once, but got %+v and %+v", console, file)
+	}
+}
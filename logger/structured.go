@@ -0,0 +1,209 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// buffer is a reusable encoding buffer
+type buffer struct {
+	b      []byte
+	fields []Field
+}
+
+// maxPooledBuffer is the capacity above which buffers are dropped instead of being returned to the pool
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return &buffer{b: make([]byte, 0, 1024), fields: make([]Field, 0, 16)}
+	},
+}
+
+func putBuffer(b *buffer) {
+	if cap(b.b) > maxPooledBuffer {
+		return
+	}
+	for i := range b.fields {
+		b.fields[i] = Field{}
+	}
+	b.b, b.fields = b.b[:0], b.fields[:0]
+	bufferPool.Put(b)
+}
+
+// Structured is a Logger which writes entries with typed fields to one or more sinks. Child loggers created with
+// With share the sinks and level of their parent.
+//
+//	l := logger.NewStructured(os.Stdout, logger.INFO, logger.JSONEncoder{})
+//	l.With(logger.String("component", "api")).Info("started", logger.Int("port", 8080))
+type Structured struct {
+	// Caller adds the file and line of the caller to entries, which costs a runtime.Caller call per entry
+	Caller bool
+
+	// ExitOnFatal exits the process after writing a FATAL entry
+	ExitOnFatal bool
+
+	sinks  []*Sink
+	level  *int32
+	fields []Field
+	now    func() time.Time
+}
+
+// NewStructured returns a structured logger which writes entries of lvl and below to w
+func NewStructured(w io.Writer, lvl LogLevel, enc Encoder) *Structured {
+	return NewStructuredSinks(lvl, NewSink(w, DEBUG, enc))
+}
+
+// NewStructuredSinks returns a structured logger which writes entries of lvl and below to every sink which accepts
+// their level
+//
+//	l := logger.NewStructuredSinks(logger.DEBUG,
+//		logger.NewSink(os.Stderr, logger.INFO, logger.ConsoleEncoder{Color: true}),
+//		logger.NewSink(file, logger.DEBUG, logger.JSONEncoder{}),
+//	)
+func NewStructuredSinks(lvl LogLevel, sinks ...*Sink) *Structured {
+	level := int32(lvl)
+	return &Structured{
+		ExitOnFatal: true,
+		sinks:       sinks,
+		level:       &level,
+		now:         time.Now,
+	}
+}
+
+// GetLevel returns the level of the logger
+func (l *Structured) GetLevel() LogLevel {
+	return LogLevel(atomic.LoadInt32(l.level))
+}
+
+// SetLevel changes the level of the logger and its children
+func (l *Structured) SetLevel(lvl LogLevel) {
+	atomic.StoreInt32(l.level, int32(lvl))
+}
+
+// Enabled reports if entries of lvl are written, it can be used to skip expensive preparation of fields
+func (l *Structured) Enabled(lvl LogLevel) bool {
+	return lvl <= l.GetLevel()
+}
+
+// With returns a child logger which adds the fields to every entry
+func (l *Structured) With(fields ...Field) *Structured {
+	c := *l
+	c.fields = make([]Field, 0, len(l.fields)+len(fields))
+	c.fields = append(c.fields, l.fields...)
+	c.fields = append(c.fields, fields...)
+	return &c
+}
+
+// Log implements the Logger interface. Arguments which are a Field are used as is, other arguments are
+// considered key/value pairs and a trailing single argument is used as the message, i.e.
+// Log(INFO, "addr", ":8080", "listening").
+func (l *Structured) Log(lvl LogLevel, args ...interface{}) {
+	if !l.Enabled(lvl) {
+		return
+	}
+
+	buf := bufferPool.Get().(*buffer)
+	buf.fields = append(buf.fields, l.fields...)
+	var msg string
+	buf.fields, msg = fieldsFromArgs(buf.fields, args)
+	l.write(buf, lvl, msg, 2)
+}
+
+// Debug writes a DEBUG entry
+func (l *Structured) Debug(msg string, fields ...Field) {
+	l.log(DEBUG, msg, fields)
+}
+
+// Info writes an INFO entry
+func (l *Structured) Info(msg string, fields ...Field) {
+	l.log(INFO, msg, fields)
+}
+
+// Warn writes a WARN entry
+func (l *Structured) Warn(msg string, fields ...Field) {
+	l.log(WARN, msg, fields)
+}
+
+// Error writes an ERROR entry
+func (l *Structured) Error(msg string, fields ...Field) {
+	l.log(ERROR, msg, fields)
+}
+
+// Fatal writes a FATAL entry and exits the process if ExitOnFatal is set
+func (l *Structured) Fatal(msg string, fields ...Field) {
+	l.log(FATAL, msg, fields)
+}
+
+func (l *Structured) log(lvl LogLevel, msg string, fields []Field) {
+	if !l.Enabled(lvl) {
+		return
+	}
+
+	buf := bufferPool.Get().(*buffer)
+	buf.fields = append(buf.fields, l.fields...)
+	buf.fields = append(buf.fields, fields...)
+	l.write(buf, lvl, msg, 3)
+}
+
+// write encodes and writes the entry and releases buf. Skip is the number of frames between write and the caller
+// of the logger.
+func (l *Structured) write(buf *buffer, lvl LogLevel, msg string, skip int) {
+	e := Entry{Time: l.now(), Level: lvl, Message: msg, Fields: buf.fields}
+	if l.Caller {
+		if _, file, line, ok := runtime.Caller(skip); ok {
+			e.Caller = shortCaller(file, line)
+		}
+	}
+
+	for _, s := range l.sinks {
+		if lvl > s.Level {
+			continue
+		}
+		buf.b = s.write(buf.b[:0], &e)
+	}
+	putBuffer(buf)
+
+	if lvl <= FATAL && l.ExitOnFatal {
+		l.Flush()
+		os.Exit(1)
+	}
+}
+
+// Flush flushes the writers of the sinks which buffer entries
+func (l *Structured) Flush() error {
+	var first error
+	for _, s := range l.sinks {
+		if err := s.Flush(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Close flushes and closes the writers of the sinks, except os.Stdout and os.Stderr
+func (l *Structured) Close() error {
+	var first error
+	for _, s := range l.sinks {
+		if err := s.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// shortCaller returns the caller as package directory, file and line, i.e. serverpool/pool.go:42
+func shortCaller(file string, line int) string {
+	if i := strings.LastIndexByte(file, '/'); i > 0 {
+		if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+	return file + ":" + strconv.Itoa(line)
+}
@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestStructured(lvl LogLevel, enc Encoder) (*Structured, *bytes.Buffer) {
+	var b bytes.Buffer
+	l := NewStructured(&b, lvl, enc)
+	l.now = func() time.Time { return testTime }
+	return l, &b
+}
+
+func TestStructured(t *testing.T) {
+	l, b := newTestStructured(INFO, LogfmtEncoder{})
+
+	api := l.With(String("component", "api"))
+	api.Info("started", Int("port", 8080))
+	api.Debug("hidden")
+	l.Warn("plain")
+
+	expected := "time=2021-03-04T10:11:12.123456789Z level=INFO msg=started component=api port=8080\n" +
+		"time=2021-03-04T10:11:12.123456789Z level=WARN msg=plain\n"
+	if b.String() != expected {
+		t.Errorf("expected %s, but got %s", expected, b.String())
+	}
+
+	b.Reset()
+	api.SetLevel(DEBUG)
+	l.Debug("visible")
+	if !strings.Contains(b.String(), "msg=visible") {
+		t.Errorf("expected the level of the parent to change with the child, but got %s", b.String())
+	}
+}
+
+func TestStructuredLog(t *testing.T) {
+	l, b := newTestStructured(DEBUG, JSONEncoder{})
+
+	l.Log(INFO, "addr", ":8080", Bool("tls", false), "retries", 3, "100% done")
+
+	var v map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &v); err != nil {
+		t.Fatalf("expected valid JSON, but got %v: %s", err, b.String())
+	}
+	if v["msg"] != "100% done" || v["addr"] != ":8080" || v["tls"] != false || v["retries"] != float64(3) {
+		t.Errorf("unexpected entry %v", v)
+	}
+
+	var _ Logger = l
+	var _ Leveled = l
+}
+
+func TestStructuredCaller(t *testing.T) {
+	l, b := newTestStructured(DEBUG, LogfmtEncoder{})
+	l.Caller = true
+
+	l.Info("typed")
+	l.Log(INFO, "untyped")
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, but got %q", b.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "caller=logger/structured_test.go:") {
+			t.Errorf("expected the test file as caller, but got %s", line)
+		}
+	}
+}
+
+func TestStructuredConcurrency(t *testing.T) {
+	l, b := newTestStructured(INFO, JSONEncoder{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := l.With(Int("worker", i))
+			for n := 0; n < 100; n++ {
+				child.Info("tick", Int("n", n), Err(errors.New("e")))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 800 {
+		t.Fatalf("expected 800 lines, but got %d", len(lines))
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Fatalf("expected valid JSON, but got %s", line)
+		}
+	}
+}
+
+func TestStructuredAllocations(t *testing.T) {
+	l := NewStructured(ioutil.Discard, INFO, JSONEncoder{}).With(String("component", "api"))
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Info("request", String("method", "GET"), Int("status", 200), Duration("took", time.Millisecond))
+	})
+	if allocs > 1 {
+		t.Errorf("expected at most 1 allocation per entry, but got %v", allocs)
+	}
+}
+
+func BenchmarkStructuredJSON(b *testing.B) {
+	l := NewStructured(ioutil.Discard, INFO, JSONEncoder{}).With(String("component", "api"))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info("request", String("method", "GET"), Int("status", 200), Duration("took", time.Millisecond))
+		}
+	})
+}
+
+func BenchmarkStructuredLogfmt(b *testing.B) {
+	l := NewStructured(ioutil.Discard, INFO, LogfmtEncoder{}).With(String("component", "api"))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info("request", String("method", "GET"), Int("status", 200), Duration("took", time.Millisecond))
+		}
+	})
+}
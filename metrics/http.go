@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// HTTPMetrics records request counts and latencies per route
+type HTTPMetrics struct {
+	requests *CounterVec
+	duration *HistogramVec
+	inFlight *Gauge
+
+	// Route returns the route label of a request. By default it's the path template of the matched mux route,
+	// so the number of series doesn't grow with the number of distinct paths.
+	Route func(*http.Request) string
+}
+
+// NewHTTPMetrics registers the HTTP metrics in r
+func NewHTTPMetrics(r *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: r.CounterVec("http_requests_total", "Total number of HTTP requests.", "method", "route", "code"),
+		duration: r.HistogramVec("http_request_duration_seconds", "Duration of HTTP requests in seconds.", nil, "method", "route"),
+		inFlight: r.Gauge("http_requests_in_flight", "Number of HTTP requests which are being served."),
+		Route:    MuxRoute,
+	}
+}
+
+// MuxRoute returns the path template of the mux route which matched the request, or "unmatched"
+func MuxRoute(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if t, err := route.GetPathTemplate(); err == nil {
+			return t
+		}
+	}
+	return "unmatched"
+}
+
+// Middleware records the metrics of the requests handled by next. It's compatible with mux.Router.Use.
+func (m *HTTPMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		start := time.Now()
+		sw := statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(&sw, r)
+
+		route := m.Route(r)
+		m.requests.With(r.Method, route, strconv.Itoa(sw.status)).Inc()
+		m.duration.With(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusWriter captures the status code of a response
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher when the underlying writer does
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the underlying writer does
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking isn't supported")
+	}
+	w.wroteHeader = true
+	w.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Push implements http.Pusher when the underlying writer does
+func (w *statusWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// ReadFrom implements io.ReaderFrom, so the underlying writer can use sendfile
+func (w *statusWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.wroteHeader = true
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(w.ResponseWriter, r)
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestMiddleware(t *testing.T) {
+	reg := NewRegistry()
+	m := NewHTTPMetrics(reg)
+
+	r := mux.NewRouter()
+	r.Use(m.Middleware)
+	r.HandleFunc("/users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	r.HandleFunc("/fail", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "failed", http.StatusInternalServerError)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/fail"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if v := m.requests.With("GET", "/users/{id}", "200").Value(); v != 2 {
+		t.Errorf("expected 2 requests for the route, but got %v", v)
+	}
+	if v := m.requests.With("GET", "/fail", "500").Value(); v != 1 {
+		t.Errorf("expected 1 failed request, but got %v", v)
+	}
+	if n := m.duration.With("GET", "/users/{id}").Count(); n != 2 {
+		t.Errorf("expected 2 latency observations, but got %d", n)
+	}
+
+	w := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("expected content type %s, but got %s", ContentType, ct)
+	}
+	if !strings.Contains(w.Body.String(), `http_requests_total{method="GET",route="/users/{id}",code="200"} 2`) {
+		t.Errorf("expected the request counter in the output, but got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,484 @@
+// Package metrics implements a dependency-free registry of counters, gauges and histograms with labels, which
+// are exposed in the Prometheus text exposition format.
+//
+//	requests := metrics.Default.CounterVec("jobs_processed_total", "Processed jobs", "queue")
+//	requests.With("emails").Inc()
+//
+// Registering a metric which already exists with the same type and labels returns the existing metric. Invalid
+// names and conflicting registrations are programming errors and panic.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Metric types
+const (
+	counterType   = "counter"
+	gaugeType     = "gauge"
+	histogramType = "histogram"
+)
+
+// ContentType is the content type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are the default histogram buckets, which are suited for durations in seconds
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	nameRe  = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Default is the default registry, which includes the Go runtime metrics
+var Default = NewRegistry()
+
+func init() {
+	RegisterRuntimeMetrics(Default)
+}
+
+// Counter is a value which only goes up
+type Counter struct {
+	bits uint64
+}
+
+// Inc increments the counter by 1
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds v to the counter, negative values are ignored
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	addFloat(&c.bits, v)
+}
+
+// Value returns the current value of the counter
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.bits))
+}
+
+// set is used for counters which mirror a value which is counted elsewhere, like the Go runtime
+func (c *Counter) set(v float64) {
+	atomic.StoreUint64(&c.bits, math.Float64bits(v))
+}
+
+// Gauge is a value which can go up and down
+type Gauge struct {
+	bits uint64
+}
+
+// Set sets the gauge to v
+func (g *Gauge) Set(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+// Inc increments the gauge by 1
+func (g *Gauge) Inc() {
+	addFloat(&g.bits, 1)
+}
+
+// Dec decrements the gauge by 1
+func (g *Gauge) Dec() {
+	addFloat(&g.bits, -1)
+}
+
+// Add adds v to the gauge
+func (g *Gauge) Add(v float64) {
+	addFloat(&g.bits, v)
+}
+
+// Value returns the current value of the gauge
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+func addFloat(bits *uint64, v float64) {
+	for {
+		old := atomic.LoadUint64(bits)
+		if atomic.CompareAndSwapUint64(bits, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// Observe adds an observation to the histogram
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// Count returns the number of observations
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Sum returns the sum of all observations
+func (h *Histogram) Sum() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sum
+}
+
+// snapshot returns the cumulative bucket counts, the count and the sum
+func (h *Histogram) snapshot() ([]uint64, uint64, float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cumulative := make([]uint64, len(h.counts))
+	var n uint64
+	for i, c := range h.counts {
+		n += c
+		cumulative[i] = n
+	}
+	return cumulative, h.count, h.sum
+}
+
+// series is a metric with its label values
+type series struct {
+	values []string
+	metric interface{}
+}
+
+// family contains all series of a metric
+type family struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+	fn      func() float64
+
+	mu     sync.RWMutex
+	series map[string]*series
+}
+
+func (f *family) with(values []string) interface{} {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, but got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	f.mu.RLock()
+	s, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return s.metric
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.series[key]; ok {
+		return s.metric
+	}
+
+	s = &series{values: append([]string{}, values...)}
+	switch f.typ {
+	case counterType:
+		s.metric = &Counter{}
+	case gaugeType:
+		s.metric = &Gauge{}
+	case histogramType:
+		s.metric = &Histogram{buckets: f.buckets, counts: make([]uint64, len(f.buckets))}
+	}
+	f.series[key] = s
+	return s.metric
+}
+
+// sorted returns the series ordered by their label values
+func (f *family) sorted() []*series {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	s := make([]*series, 0, len(f.series))
+	for _, v := range f.series {
+		s = append(s, v)
+	}
+	sort.Slice(s, func(i, j int) bool {
+		a, b := s[i].values, s[j].values
+		for n := range a {
+			if a[n] != b[n] {
+				return a[n] < b[n]
+			}
+		}
+		return false
+	})
+	return s
+}
+
+// CounterVec is a counter with labels
+type CounterVec struct {
+	f *family
+}
+
+// With returns the counter for the label values, in the order of the labels
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.f.with(values).(*Counter)
+}
+
+// GaugeVec is a gauge with labels
+type GaugeVec struct {
+	f *family
+}
+
+// With returns the gauge for the label values, in the order of the labels
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.f.with(values).(*Gauge)
+}
+
+// HistogramVec is a histogram with labels
+type HistogramVec struct {
+	f *family
+}
+
+// With returns the histogram for the label values, in the order of the labels
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.f.with(values).(*Histogram)
+}
+
+// Registry contains metrics
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+	hooks    []func()
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// register adds a family or returns the existing family with the same name
+func (r *Registry) register(f *family) *family {
+	if !nameRe.MatchString(f.name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", f.name))
+	}
+	for _, l := range f.labels {
+		if !labelRe.MatchString(l) || strings.HasPrefix(l, "__") || (f.typ == histogramType && l == "le") {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %s", l, f.name))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e, ok := r.families[f.name]; ok {
+		if e.typ != f.typ || strings.Join(e.labels, ",") != strings.Join(f.labels, ",") || (e.fn == nil) != (f.fn == nil) {
+			panic(fmt.Sprintf("metrics: %s is already registered with a different type or labels", f.name))
+		}
+		return e
+	}
+
+	f.series = make(map[string]*series)
+	r.families[f.name] = f
+	return f
+}
+
+// CounterVec registers a counter with labels
+func (r *Registry) CounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{r.register(&family{name: name, help: help, typ: counterType, labels: labels})}
+}
+
+// Counter registers a counter without labels
+func (r *Registry) Counter(name, help string) *Counter {
+	return r.CounterVec(name, help).With()
+}
+
+// GaugeVec registers a gauge with labels
+func (r *Registry) GaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{r.register(&family{name: name, help: help, typ: gaugeType, labels: labels})}
+}
+
+// Gauge registers a gauge without labels
+func (r *Registry) Gauge(name, help string) *Gauge {
+	return r.GaugeVec(name, help).With()
+}
+
+// GaugeFunc registers a gauge which value is returned by fn when the metrics are collected
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(&family{name: name, help: help, typ: gaugeType, fn: fn})
+}
+
+// HistogramVec registers a histogram with labels. The buckets are the upper bounds of the buckets, when nil
+// DefBuckets are used.
+func (r *Registry) HistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	b := append([]float64{}, buckets...)
+	sort.Float64s(b)
+	return &HistogramVec{r.register(&family{name: name, help: help, typ: histogramType, labels: labels, buckets: b})}
+}
+
+// Histogram registers a histogram without labels
+func (r *Registry) Histogram(name, help string, buckets []float64) *Histogram {
+	return r.HistogramVec(name, help, buckets).With()
+}
+
+// OnCollect registers a hook which is called before the metrics are written, i.e. to update gauges
+func (r *Registry) OnCollect(f func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, f)
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	hooks := append([]func(){}, r.hooks...)
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	for _, h := range hooks {
+		h()
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	cw := countingWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.write(&cw)
+	}
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// Handler returns an http.Handler which serves the metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteTo(w)
+	})
+}
+
+func (f *family) write(w *countingWriter) {
+	w.printf("# HELP %s %s\n", f.name, escapeHelp(f.help))
+	w.printf("# TYPE %s %s\n", f.name, f.typ)
+
+	if f.fn != nil {
+		w.printf("%s %s\n", f.name, formatFloat(f.fn()))
+		return
+	}
+
+	for _, s := range f.sorted() {
+		switch m := s.metric.(type) {
+		case *Counter:
+			w.printf("%s%s %s\n", f.name, formatLabels(f.labels, s.values, "", ""), formatFloat(m.Value()))
+		case *Gauge:
+			w.printf("%s%s %s\n", f.name, formatLabels(f.labels, s.values, "", ""), formatFloat(m.Value()))
+		case *Histogram:
+			counts, count, sum := m.snapshot()
+			for i, b := range f.buckets {
+				w.printf("%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.values, "le", formatFloat(b)), counts[i])
+			}
+			w.printf("%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.values, "le", "+Inf"), count)
+			w.printf("%s_sum%s %s\n", f.name, formatLabels(f.labels, s.values, "", ""), formatFloat(sum))
+			w.printf("%s_count%s %d\n", f.name, formatLabels(f.labels, s.values, "", ""), count)
+		}
+	}
+}
+
+// formatLabels formats the label pairs, including an optional extra label
+func formatLabels(labels, values []string, extra, extraValue string) string {
+	if len(labels) == 0 && extra == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	if extra != "" {
+		if len(labels) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extra)
+		b.WriteString(`="`)
+		b.WriteString(extraValue)
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countingWriter keeps track of the written bytes and the first error
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
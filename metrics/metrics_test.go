@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExposition(t *testing.T) {
+	r := NewRegistry()
+
+	c := r.CounterVec("jobs_total", "Processed jobs.", "queue", "status")
+	c.With("emails", "ok").Add(3)
+	c.With("emails", "failed").Inc()
+	c.With(`a"b\c`+"\n", "ok").Inc()
+
+	g := r.Gauge("queue_depth", "Jobs in the queue.\nIncluding retries.")
+	g.Set(5)
+	g.Dec()
+
+	h := r.HistogramVec("job_duration_seconds", "Job duration.", []float64{1, 0.1}, "queue")
+	for _, v := range []float64{0.05, 0.1, 0.5, 2} {
+		h.With("emails").Observe(v)
+	}
+
+	r.GaugeFunc("temperature", "Temperature.", func() float64 { return math.Inf(1) })
+
+	var b bytes.Buffer
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatalf("expected WriteTo to pass, but got %v", err)
+	}
+
+	expected := `# HELP job_duration_seconds Job duration.
+# TYPE job_duration_seconds histogram
+job_duration_seconds_bucket{queue="emails",le="0.1"} 2
+job_duration_seconds_bucket{queue="emails",le="1"} 3
+job_duration_seconds_bucket{queue="emails",le="+Inf"} 4
+job_duration_seconds_sum{queue="emails"} 2.65
+job_duration_seconds_count{queue="emails"} 4
+# HELP jobs_total Processed jobs.
+# TYPE jobs_total counter
+jobs_total{queue="a\"b\\c\n",status="ok"} 1
+jobs_total{queue="emails",status="failed"} 1
+jobs_total{queue="emails",status="ok"} 3
+# HELP queue_depth Jobs in the queue.\nIncluding retries.
+# TYPE queue_depth gauge
+queue_depth 4
+# HELP temperature Temperature.
+# TYPE temperature gauge
+temperature +Inf
+`
+	if got := b.String(); got != expected {
+		t.Errorf("unexpected exposition:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestRegistration(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("requests_total", "Requests.")
+	c.Inc()
+	c.Add(-1)
+	if again := r.Counter("requests_total", "Requests."); again != c || again.Value() != 1 {
+		t.Errorf("expected the existing counter to be returned")
+	}
+
+	panics := map[string]func(){
+		"conflicting type":   func() { r.Gauge("requests_total", "Requests.") },
+		"conflicting labels": func() { r.CounterVec("requests_total", "Requests.", "code") },
+		"invalid name":       func() { r.Counter("requests-total", "Requests.") },
+		"invalid label":      func() { r.CounterVec("errors_total", "Errors.", "__name") },
+		"le label":           func() { r.HistogramVec("latency", "Latency.", nil, "le") },
+		"label values":       func() { r.CounterVec("codes_total", "Codes.", "code").With() },
+	}
+	for name, f := range panics {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	r := NewRegistry()
+	c := r.CounterVec("hits_total", "Hits.", "worker")
+	h := r.Histogram("latency_seconds", "Latency.", nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				c.With("w").Inc()
+				h.Observe(0.01)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v := c.With("w").Value(); v != 8000 {
+		t.Errorf("expected 8000 hits, but got %v", v)
+	}
+	if n := h.Count(); n != 8000 {
+		t.Errorf("expected 8000 observations, but got %d", n)
+	}
+}
+
+func TestRuntimeMetrics(t *testing.T) {
+	var b bytes.Buffer
+	Default.WriteTo(&b)
+	for _, name := range []string{"go_goroutines ", "go_memstats_alloc_bytes ", "go_gc_cycles_total ", `go_info{version="go`} {
+		if !strings.Contains(b.String(), name) {
+			t.Errorf("expected the default registry to contain %s", name)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+)
+
+// RegisterRuntimeMetrics registers metrics about the Go runtime and the process. The memory statistics are read
+// once per collection.
+func RegisterRuntimeMetrics(r *Registry) {
+	r.GaugeVec("go_info", "Information about the Go environment.", "version").With(runtime.Version()).Set(1)
+	r.Gauge("process_start_time_seconds", "Start time of the process since unix epoch in seconds.").
+		Set(float64(time.Now().UnixNano()) / 1e9)
+
+	r.GaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	r.GaugeFunc("go_threads", "Number of OS threads created.", func() float64 {
+		n, _ := runtime.ThreadCreateProfile(nil)
+		return float64(n)
+	})
+
+	var (
+		alloc      = r.Gauge("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.")
+		heapInuse  = r.Gauge("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.")
+		heapObj    = r.Gauge("go_memstats_heap_objects", "Number of allocated objects.")
+		stackInuse = r.Gauge("go_memstats_stack_inuse_bytes", "Number of bytes in use by the stack allocator.")
+		sys        = r.Gauge("go_memstats_sys_bytes", "Number of bytes obtained from system.")
+		mallocs    = r.Counter("go_memstats_mallocs_total", "Total number of mallocs.")
+		frees      = r.Counter("go_memstats_frees_total", "Total number of frees.")
+		lastGC     = r.Gauge("go_memstats_last_gc_time_seconds", "Number of seconds since 1970 of last garbage collection.")
+		gcCycles   = r.Counter("go_gc_cycles_total", "Number of completed GC cycles.")
+		gcPause    = r.Counter("go_gc_pause_seconds_total", "Total time spent in GC stop-the-world pauses.")
+	)
+
+	r.OnCollect(func() {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+
+		alloc.Set(float64(m.Alloc))
+		heapInuse.Set(float64(m.HeapInuse))
+		heapObj.Set(float64(m.HeapObjects))
+		stackInuse.Set(float64(m.StackInuse))
+		sys.Set(float64(m.Sys))
+		mallocs.set(float64(m.Mallocs))
+		frees.set(float64(m.Frees))
+		lastGC.Set(float64(m.LastGC) / 1e9)
+		gcCycles.set(float64(m.NumGC))
+		gcPause.set(float64(m.PauseTotalNs) / 1e9)
+	})
+}
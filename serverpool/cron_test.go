@@ -0,0 +1,56 @@
+package serverpool
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	base := time.Date(2021, time.September, 1, 10, 7, 30, 0, time.UTC) // Wednesday
+
+	tests := []struct {
+		spec string
+		next []string
+	}{
+		{"* * * * *", []string{"2021-09-01 10:08", "2021-09-01 10:09"}},
+		{"*/15 * * * *", []string{"2021-09-01 10:15", "2021-09-01 10:30", "2021-09-01 10:45", "2021-09-01 11:00"}},
+		{"5/20 9-10 * * *", []string{"2021-09-01 10:25", "2021-09-01 10:45", "2021-09-02 09:05"}},
+		{"0 9 * * mon-fri", []string{"2021-09-02 09:00", "2021-09-03 09:00", "2021-09-06 09:00"}},
+		{"30 8 1,15 * *", []string{"2021-09-15 08:30", "2021-10-01 08:30"}},
+		{"0 0 13 * 5", []string{"2021-09-03 00:00", "2021-09-10 00:00", "2021-09-13 00:00"}},
+		{"0 12 * * 7", []string{"2021-09-05 12:00"}},
+		{"0 0 29 feb *", []string{"2024-02-29 00:00"}},
+		{"@daily", []string{"2021-09-02 00:00"}},
+		{"@monthly", []string{"2021-10-01 00:00", "2021-11-01 00:00"}},
+		{"@every 90s", []string{"2021-09-01 10:09", "2021-09-01 10:10"}},
+	}
+
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("%s: expected ParseSchedule to pass, but got %v", tt.spec, err)
+			continue
+		}
+		next := base
+		for _, expected := range tt.next {
+			next = s.Next(next)
+			if got := next.Format("2006-01-02 15:04"); got != expected {
+				t.Errorf("%s: expected %s, but got %s", tt.spec, expected, got)
+				break
+			}
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"*/0 * * * *", "5-1 * * * *", "* * * * foo", "@every", "@every -1s", "@sometimes"} {
+		if _, err := ParseSchedule(spec); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("%q: expected ErrInvalidSchedule, but got %v", spec, err)
+		}
+	}
+
+	s, _ := ParseSchedule("0 0 31 2 *")
+	if next := s.Next(base); !next.IsZero() {
+		t.Errorf("expected February 31 to never run, but got %v", next)
+	}
+}
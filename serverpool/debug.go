@@ -0,0 +1,206 @@
+package serverpool
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arjanvaneersel/kit/logger"
+)
+
+// Build information, which is set at build time with:
+//
+//	go build -ldflags "-X github.com/arjanvaneersel/kit/serverpool.Version=1.2.3 \
+//		-X github.com/arjanvaneersel/kit/serverpool.Revision=$(git rev-parse HEAD) \
+//		-X github.com/arjanvaneersel/kit/serverpool.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Revision  = ""
+	BuildTime = ""
+)
+
+var ErrUnprotectedDebug = errors.New("debug routes require a token or localhost only access")
+
+// DebugOptions configure the debug routes of a DiagnosticsServer
+type DebugOptions struct {
+	// Token is required as "Authorization: Bearer <token>" for all debug routes
+	Token string
+
+	// LocalhostOnly only accepts requests from loopback addresses. Behind a reverse proxy every request
+	// originates from the proxy, so use a token instead.
+	LocalhostOnly bool
+
+	// Logger is the logger which level is served and changed at /debug/loglevel
+	Logger logger.Leveled
+}
+
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+	Path      string `json:"path,omitempty"`
+	Module    string `json:"module_version,omitempty"`
+}
+
+// ReadBuildInfo returns the build information of the running binary
+func ReadBuildInfo() BuildInfo {
+	bi := BuildInfo{
+		Version:   Version,
+		Revision:  Revision,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		bi.Path = info.Path
+		bi.Module = info.Main.Version
+	}
+	return bi
+}
+
+// EnableDebug adds the debug routes to the router of the server:
+//
+//	/debug/pprof/     pprof profiles
+//	/debug/vars       expvar variables
+//	/debug/goroutines stack traces of all goroutines
+//	/debug/buildinfo  version and revision of the binary
+//	/debug/loglevel   GET returns the log level, PUT or POST with ?level=DEBUG changes it
+//
+// The routes have to be protected with a token, localhost only access or both. EnableDebug has to be called
+// before the server is started.
+func (s *DiagnosticsServer) EnableDebug(o DebugOptions) error {
+	if o.Token == "" && !o.LocalhostOnly {
+		return ErrUnprotectedDebug
+	}
+
+	// Keep the connection of requests, so profiles and traces can extend their write deadline
+	connContext := s.ConnContext
+	s.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		if connContext != nil {
+			ctx = connContext(ctx, c)
+		}
+		return context.WithValue(ctx, connKey{}, c)
+	}
+
+	r := s.Router.PathPrefix("/debug").Subrouter()
+	r.Use(o.guard)
+
+	r.HandleFunc("/pprof/cmdline", pprof.Cmdline)
+	r.HandleFunc("/pprof/profile", s.extendDeadline(pprof.Profile, 30))
+	r.HandleFunc("/pprof/symbol", pprof.Symbol)
+	r.HandleFunc("/pprof/trace", s.extendDeadline(pprof.Trace, 1))
+	r.PathPrefix("/pprof/").HandlerFunc(pprof.Index)
+	r.Handle("/vars", expvar.Handler())
+	r.HandleFunc("/goroutines", goroutinesHandler)
+	r.HandleFunc("/buildinfo", buildInfoHandler)
+	if o.Logger != nil {
+		r.HandleFunc("/loglevel", logLevelHandler(o.Logger, s.logger))
+	}
+	return nil
+}
+
+// connKey is the context key of the connection of a request
+type connKey struct{}
+
+// extendDeadline extends the write deadline of the connection by the duration of a profile or trace, which is
+// the seconds parameter or def. Other routes keep the write timeout of the server. net/http/pprof rejects
+// durations beyond the write timeout of the server, so the handler gets a server without one.
+func (s *DiagnosticsServer) extendDeadline(h http.HandlerFunc, def float64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sec, err := strconv.ParseFloat(r.FormValue("seconds"), 64)
+		if err != nil || sec <= 0 {
+			sec = def
+		}
+
+		// HTTP/2 connections are shared by requests, so their deadline isn't extended and pprof keeps rejecting
+		// durations beyond the write timeout
+		c, ok := r.Context().Value(connKey{}).(net.Conn)
+		if !ok || r.ProtoMajor != 1 {
+			h(w, r)
+			return
+		}
+		c.SetWriteDeadline(time.Now().Add(time.Duration(sec*float64(time.Second)) + s.WriteTimeout))
+		h(w, r.WithContext(context.WithValue(r.Context(), http.ServerContextKey, &http.Server{})))
+	}
+}
+
+// guard rejects requests which aren't allowed by the options
+func (o DebugOptions) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if o.LocalhostOnly && !isLoopback(r.RemoteAddr) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		if o.Token != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(o.Token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="debug"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func goroutinesHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rpprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+func buildInfoHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReadBuildInfo())
+}
+
+// logLevelHandler serves and changes the level of target, changes are logged to l
+func logLevelHandler(target logger.Leveled, l logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			v := r.URL.Query().Get("level")
+			if v == "" {
+				b, _ := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 64))
+				v = strings.TrimSpace(string(b))
+			}
+			lvl, err := logger.StringToLogLevel(v)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			old := target.GetLevel()
+			target.SetLevel(lvl)
+			l.Log(logger.WARN, "log level changed from", old, "to", lvl, "by", r.RemoteAddr)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(target.GetLevel().String() + "\n"))
+	}
+}
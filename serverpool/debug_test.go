@@ -0,0 +1,146 @@
+package serverpool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjanvaneersel/kit/logger"
+)
+
+func debugRequest(d *DiagnosticsServer, method, path, remote, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remote
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	d.Handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestEnableDebug(t *testing.T) {
+	d := NewDiagnosticsServer(0, testLogger)
+	if err := d.EnableDebug(DebugOptions{}); err != ErrUnprotectedDebug {
+		t.Fatalf("expected ErrUnprotectedDebug, but got %v", err)
+	}
+	if err := d.EnableDebug(DebugOptions{Token: "secret"}); err != nil {
+		t.Fatalf("expected EnableDebug to pass, but got %v", err)
+	}
+
+	if w := debugRequest(d, http.MethodGet, "/debug/vars", "10.0.0.1:1234", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, but got %d", w.Code)
+	}
+	if w := debugRequest(d, http.MethodGet, "/debug/vars", "10.0.0.1:1234", "wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with a wrong token, but got %d", w.Code)
+	}
+
+	w := debugRequest(d, http.MethodGet, "/debug/vars", "10.0.0.1:1234", "secret")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "memstats") {
+		t.Errorf("expected the expvar variables, but got %d", w.Code)
+	}
+	w = debugRequest(d, http.MethodGet, "/debug/pprof/", "10.0.0.1:1234", "secret")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine") {
+		t.Errorf("expected the pprof index, but got %d", w.Code)
+	}
+	w = debugRequest(d, http.MethodGet, "/debug/goroutines", "10.0.0.1:1234", "secret")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine ") {
+		t.Errorf("expected a goroutine dump, but got %d", w.Code)
+	}
+
+	w = debugRequest(d, http.MethodGet, "/debug/buildinfo", "10.0.0.1:1234", "secret")
+	var bi BuildInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &bi); err != nil {
+		t.Fatalf("expected build info as JSON, but got %v: %s", err, w.Body.String())
+	}
+	if bi.Version != Version || bi.GoVersion == "" {
+		t.Errorf("unexpected build info %+v", bi)
+	}
+
+	if w := debugRequest(d, http.MethodGet, "/debug/loglevel", "10.0.0.1:1234", "secret"); w.Code != http.StatusNotFound {
+		t.Errorf("expected no log level route without a logger, but got %d", w.Code)
+	}
+}
+
+func TestDebugLocalhostOnly(t *testing.T) {
+	d := NewDiagnosticsServer(0, testLogger)
+	d.EnableDebug(DebugOptions{LocalhostOnly: true})
+
+	if w := debugRequest(d, http.MethodGet, "/debug/buildinfo", "192.168.1.10:1234", ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a remote address, but got %d", w.Code)
+	}
+	for _, addr := range []string{"127.0.0.1:1234", "[::1]:1234"} {
+		if w := debugRequest(d, http.MethodGet, "/debug/buildinfo", addr, ""); w.Code != http.StatusOK {
+			t.Errorf("expected 200 for %s, but got %d", addr, w.Code)
+		}
+	}
+}
+
+func TestDebugLogLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := logger.NewStdLog(logger.INFO, log.New(&b, "", 0))
+
+	d := NewDiagnosticsServer(0, testLogger)
+	d.EnableDebug(DebugOptions{LocalhostOnly: true, Logger: l})
+
+	const local = "127.0.0.1:1234"
+	if w := debugRequest(d, http.MethodGet, "/debug/loglevel", local, ""); strings.TrimSpace(w.Body.String()) != "INFO" {
+		t.Errorf("expected INFO, but got %q", w.Body.String())
+	}
+
+	l.Log(logger.DEBUG, "hidden")
+	if w := debugRequest(d, http.MethodPut, "/debug/loglevel?level=DEBUG", local, ""); w.Code != http.StatusOK {
+		t.Fatalf("expected the level to change, but got %d: %s", w.Code, w.Body.String())
+	}
+	l.Log(logger.DEBUG, "visible")
+	if strings.Contains(b.String(), "hidden") || !strings.Contains(b.String(), "visible") {
+		t.Errorf("expected only the debug line after the change, but got %q", b.String())
+	}
+
+	if w := debugRequest(d, http.MethodPut, "/debug/loglevel?level=LOUD", local, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown level, but got %d", w.Code)
+	}
+	if w := debugRequest(d, http.MethodDelete, "/debug/loglevel", local, ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, but got %d", w.Code)
+	}
+	if l.GetLevel() != logger.DEBUG {
+		t.Errorf("expected the level to stay DEBUG, but got %v", l.GetLevel())
+	}
+}
+
+func TestDebugProfile(t *testing.T) {
+	d := NewDiagnosticsServer(0, testLogger)
+	d.WriteTimeout = 200 * time.Millisecond
+	d.EnableDebug(DebugOptions{Token: "secret"})
+	if d.WriteTimeout != 200*time.Millisecond {
+		t.Errorf("expected the write timeout of the server to be kept, but got %v", d.WriteTimeout)
+	}
+
+	go d.Start()
+	defer d.Stop(context.Background())
+	for d.Probe(context.Background()) != nil {
+		time.Sleep(time.Millisecond)
+	}
+	_, port, _ := net.SplitHostPort(d.Address())
+
+	// The profile runs longer than the write timeout of the server
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:"+port+"/debug/pprof/profile?seconds=1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected a profile, but got %v", err)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil || res.StatusCode != http.StatusOK || len(body) == 0 {
+		t.Errorf("expected a profile, but got %d: %v %s", res.StatusCode, err, body)
+	}
+}
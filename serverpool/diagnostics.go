@@ -2,18 +2,31 @@ package serverpool
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync/atomic"
 	"time"
 
+	"github.com/arjanvaneersel/kit/health"
 	"github.com/arjanvaneersel/kit/logger"
+	"github.com/arjanvaneersel/kit/metrics"
 	"github.com/gorilla/mux"
 )
 
+var ErrNotReady = errors.New("pool isn't ready")
+
 type DiagnosticsServer struct {
-	http.Server
+	HTTPServer
 	Router *mux.Router
+
+	// Metrics is the registry served at /metrics, metrics.Default by default
+	Metrics *metrics.Registry
+
+	// Health contains the checks for /healthz (liveness), /readyz (readiness) and /startupz (startup). The
+	// readiness of the pool is registered as the "pool" check.
+	Health *health.Registry
+
 	ready  *atomic.Value
 	logger logger.Logger
 }
@@ -23,45 +36,63 @@ func (s *DiagnosticsServer) Ready(v bool) {
 	s.ready.Store(v)
 }
 
-func (s *DiagnosticsServer) Start() error {
-	return s.ListenAndServe()
-}
-
-func (s *DiagnosticsServer) Stop(ctx context.Context) error {
-	return s.Shutdown(ctx)
+// checkReady is the readiness and startup check of the pool
+func (s *DiagnosticsServer) checkReady(context.Context) error {
+	if s.ready == nil || !s.ready.Load().(bool) {
+		return ErrNotReady
+	}
+	return nil
 }
 
-func (s *DiagnosticsServer) Address() string {
-	return s.Addr
+func (s *DiagnosticsServer) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	s.Health.Handler(health.Liveness).ServeHTTP(w, r)
 }
 
-func (s *DiagnosticsServer) healthzHandler(w http.ResponseWriter, _ *http.Request) {
-	w.WriteHeader(http.StatusOK)
+func (s *DiagnosticsServer) startupzHandler(w http.ResponseWriter, r *http.Request) {
+	s.Health.Handler(health.Startup).ServeHTTP(w, r)
 }
 
-func (s *DiagnosticsServer) readyzHandler(w http.ResponseWriter, _ *http.Request) {
-	if s.ready == nil || !s.ready.Load().(bool) {
-		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+func (s *DiagnosticsServer) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if s.Metrics == nil {
+		http.NotFound(w, r)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+	s.Metrics.Handler().ServeHTTP(w, r)
+}
+
+func (s *DiagnosticsServer) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	s.Health.Handler(health.Readiness).ServeHTTP(w, r)
 }
 
 func NewDiagnosticsServer(port int, l logger.Logger) *DiagnosticsServer {
 	s := DiagnosticsServer{
 		ready: &atomic.Value{},
-		Server: http.Server{
-			Addr:           fmt.Sprintf(":%d", port),
-			ReadTimeout:    10 * time.Second,
-			WriteTimeout:   10 * time.Second,
-			MaxHeaderBytes: 1 << 20,
+		HTTPServer: HTTPServer{
+			Server: http.Server{
+				Addr:           fmt.Sprintf(":%d", port),
+				ReadTimeout:    10 * time.Second,
+				WriteTimeout:   10 * time.Second,
+				MaxHeaderBytes: 1 << 20,
+			},
 		},
-		logger: l,
+		logger:  l,
+		Metrics: metrics.Default,
+		Health:  health.NewRegistry(),
 	}
 
+	s.Health.Register(health.Check{
+		Name:     "pool",
+		Kind:     health.Readiness | health.Startup,
+		Check:    s.checkReady,
+		CacheTTL: -1,
+	})
+
 	r := mux.NewRouter()
 	r.HandleFunc("/healthz", s.healthzHandler)
 	r.HandleFunc("/readyz", s.readyzHandler)
+	r.HandleFunc("/startupz", s.startupzHandler)
+	r.HandleFunc("/metrics", s.metricsHandler)
+	s.Router = r
 	s.Handler = r
 
 	s.ready.Store(false)
@@ -0,0 +1,52 @@
+package serverpool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/arjanvaneersel/kit/health"
+)
+
+func probeCode(d *DiagnosticsServer, path string) (int, string) {
+	w := httptest.NewRecorder()
+	d.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w.Code, w.Body.String()
+}
+
+func TestDiagnosticsProbes(t *testing.T) {
+	d := NewDiagnosticsServer(0, testLogger)
+	d.Health.Register(health.Check{Name: "db", Kind: health.Liveness, Check: func(context.Context) error { return nil }})
+
+	if code, _ := probeCode(d, "/healthz"); code != http.StatusOK {
+		t.Errorf("expected /healthz to pass, but got %d", code)
+	}
+	if code, body := probeCode(d, "/readyz?verbose"); code != http.StatusServiceUnavailable || !strings.Contains(body, ErrNotReady.Error()) {
+		t.Errorf("expected /readyz to report the pool as not ready, but got %d: %s", code, body)
+	}
+	if code, _ := probeCode(d, "/startupz"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected /startupz to fail, but got %d", code)
+	}
+
+	d.Ready(true)
+	for _, path := range []string{"/readyz", "/startupz"} {
+		if code, _ := probeCode(d, path); code != http.StatusOK {
+			t.Errorf("expected %s to pass, but got %d", path, code)
+		}
+	}
+
+	d.Ready(false)
+	if code, _ := probeCode(d, "/startupz"); code != http.StatusOK {
+		t.Errorf("expected /startupz to stay ok once started, but got %d", code)
+	}
+
+	d.Health.Register(health.Check{Name: "deadlock", Kind: health.Liveness, Check: func(context.Context) error {
+		return errors.New("stuck")
+	}})
+	if code, _ := probeCode(d, "/healthz"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected /healthz to fail, but got %d", code)
+	}
+}
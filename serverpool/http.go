@@ -0,0 +1,219 @@
+package serverpool
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var ErrNotListening = errors.New("server isn't listening yet")
+
+// DefaultCertCheckInterval is the interval in which certificate files are checked for changes
+const DefaultCertCheckInterval = time.Minute
+
+// HTTPServer is a Server for http.Handlers. It supports TLS with certificates which are reloaded from disk when
+// they change, HTTP/2 over TLS and HTTP/2 without TLS (h2c).
+type HTTPServer struct {
+	http.Server
+
+	// CertFile and KeyFile enable TLS. The files are checked for changes every CertCheckInterval and when the
+	// server is reloaded, so renewed certificates are used without a restart.
+	CertFile          string
+	KeyFile           string
+	CertCheckInterval time.Duration
+
+	// H2C enables HTTP/2 without TLS, i.e. for servers behind a proxy which terminates TLS
+	H2C bool
+
+	// DisableHTTP2 only serves HTTP/1.x
+	DisableHTTP2 bool
+
+	mu       sync.Mutex
+	listener net.Listener
+	handler  http.Handler
+
+	certMu      sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	certChecked time.Time
+}
+
+// NewHTTPServer returns an HTTPServer for h with sensible timeouts. Use port 0 in addr to listen on a random port.
+func NewHTTPServer(addr string, h http.Handler) *HTTPServer {
+	return &HTTPServer{
+		Server: http.Server{
+			Addr:              addr,
+			Handler:           h,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       10 * time.Second,
+			WriteTimeout:      10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			MaxHeaderBytes:    1 << 20,
+		},
+	}
+}
+
+// Start listens on the address of the server and serves requests until the server is stopped
+func (s *HTTPServer) Start() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":http"
+		if s.CertFile != "" {
+			addr = ":https"
+		}
+	}
+
+	if err := s.configure(); err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.listener = ln
+	s.mu.Unlock()
+
+	if s.CertFile != "" {
+		return s.ServeTLS(ln, "", "")
+	}
+	return s.Serve(ln)
+}
+
+// configure sets up TLS, HTTP/2 and h2c before the server is started
+func (s *HTTPServer) configure() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.DisableHTTP2 && s.TLSNextProto == nil {
+		s.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+
+	if s.H2C && !s.DisableHTTP2 && s.handler == nil {
+		h := s.Handler
+		if h == nil {
+			h = http.DefaultServeMux
+		}
+		s.handler = h
+		s.Handler = h2c.NewHandler(h, &http2.Server{IdleTimeout: s.IdleTimeout})
+	}
+
+	if s.CertFile == "" {
+		return nil
+	}
+	if err := s.loadCertificate(); err != nil {
+		return err
+	}
+	if s.TLSConfig == nil {
+		s.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	s.TLSConfig.GetCertificate = s.getCertificate
+	return nil
+}
+
+// Stop gracefully shuts down the server
+func (s *HTTPServer) Stop(ctx context.Context) error {
+	return s.Shutdown(ctx)
+}
+
+// Address returns the address the server listens on. Once the server is started this is the bound address,
+// which contains the actual port when the server was configured with port 0.
+func (s *HTTPServer) Address() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return s.listener.Addr().String()
+	}
+	return s.Addr
+}
+
+// Probe reports if the server is listening, which makes the server ready for the pool
+func (s *HTTPServer) Probe(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return ErrNotListening
+	}
+	return nil
+}
+
+// Reload reloads the TLS certificate from disk
+func (s *HTTPServer) Reload() error {
+	if s.CertFile == "" {
+		return nil
+	}
+	return s.loadCertificate()
+}
+
+// loadCertificate loads the key pair and remembers the modification time of the files
+func (s *HTTPServer) loadCertificate() error {
+	modTime, err := certModTime(s.CertFile, s.KeyFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	s.certMu.Lock()
+	defer s.certMu.Unlock()
+	s.cert = &cert
+	s.certModTime = modTime
+	s.certChecked = time.Now()
+	return nil
+}
+
+// getCertificate returns the current certificate and reloads it when the files changed. When the new files
+// can't be loaded, i.e. because only the certificate has been replaced yet, the previous certificate is used.
+func (s *HTTPServer) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	interval := s.CertCheckInterval
+	if interval <= 0 {
+		interval = DefaultCertCheckInterval
+	}
+
+	s.certMu.Lock()
+	cert, modTime := s.cert, s.certModTime
+	due := time.Since(s.certChecked) >= interval
+	if due {
+		s.certChecked = time.Now()
+	}
+	s.certMu.Unlock()
+
+	if due {
+		if t, err := certModTime(s.CertFile, s.KeyFile); err == nil && t.After(modTime) {
+			if err := s.loadCertificate(); err == nil {
+				s.certMu.Lock()
+				cert = s.cert
+				s.certMu.Unlock()
+			}
+		}
+	}
+	return cert, nil
+}
+
+// certModTime returns the latest modification time of the files
+func certModTime(files ...string) (time.Time, error) {
+	var t time.Time
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if fi.ModTime().After(t) {
+			t = fi.ModTime()
+		}
+	}
+	return t, nil
+}
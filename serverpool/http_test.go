@@ -0,0 +1,224 @@
+package serverpool
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// writeCertificate writes a self-signed certificate for localhost with the common name cn
+func writeCertificate(t *testing.T, certFile, keyFile, cn string) {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &k.PublicKey, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kb, err := x509.MarshalECPrivateKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kb}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startPool starts the items and waits until the pool is ready
+func startPool(t *testing.T, items ...Item) *Pool {
+	p := New(testLogger, items...)
+	p.ProbeInterval = 5 * time.Millisecond
+	if _, err := p.start(context.Background()); err != nil {
+		t.Fatalf("expected start to pass, but got %v", err)
+	}
+	for _, i := range items {
+		for start := time.Now(); probe(i)(context.Background()) != nil; time.Sleep(time.Millisecond) {
+			if time.Since(start) > time.Second {
+				t.Fatalf("expected %s to be listening", i.Name)
+			}
+		}
+	}
+	return p
+}
+
+var hello = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(r.Proto))
+})
+
+func get(t *testing.T, c *http.Client, url string) string {
+	resp, err := c.Get(url)
+	if err != nil {
+		t.Fatalf("expected GET %s to pass, but got %v", url, err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	return string(b)
+}
+
+func TestHTTPServer(t *testing.T) {
+	s := NewHTTPServer("127.0.0.1:0", hello)
+	p := startPool(t, Item{Name: "api", Server: s})
+	defer p.Stop()
+
+	if strings.HasSuffix(s.Address(), ":0") {
+		t.Fatalf("expected the bound address, but got %s", s.Address())
+	}
+	if got := get(t, http.DefaultClient, "http://"+s.Address()); got != "HTTP/1.1" {
+		t.Errorf("expected HTTP/1.1, but got %s", got)
+	}
+}
+
+func TestH2C(t *testing.T) {
+	s := NewHTTPServer("127.0.0.1:0", hello)
+	s.H2C = true
+	p := startPool(t, Item{Name: "api", Server: s})
+	defer p.Stop()
+
+	c := http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	if got := get(t, &c, "http://"+s.Address()); got != "HTTP/2.0" {
+		t.Errorf("expected HTTP/2.0, but got %s", got)
+	}
+}
+
+func TestTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile, "first")
+
+	s := NewHTTPServer("127.0.0.1:0", hello)
+	s.CertFile, s.KeyFile = certFile, keyFile
+	p := startPool(t, Item{Name: "api", Server: s})
+	defer p.Stop()
+
+	commonName := func() string {
+		c := http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+		}}
+		resp, err := c.Get("https://" + s.Address())
+		if err != nil {
+			t.Fatalf("expected GET to pass, but got %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.Proto != "HTTP/2.0" {
+			t.Errorf("expected HTTP/2.0, but got %s", resp.Proto)
+		}
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	if cn := commonName(); cn != "first" {
+		t.Errorf("expected the first certificate, but got %s", cn)
+	}
+
+	writeCertificate(t, certFile, keyFile, "second")
+	p.reload()
+	if cn := commonName(); cn != "second" {
+		t.Errorf("expected the reloaded certificate, but got %s", cn)
+	}
+
+	writeCertificate(t, certFile, keyFile, "third")
+	s.CertCheckInterval = time.Nanosecond
+	if cn := commonName(); cn != "third" {
+		t.Errorf("expected the changed certificate to be picked up, but got %s", cn)
+	}
+}
+
+func TestListenerServer(t *testing.T) {
+	s := NewListenerServer("tcp", "127.0.0.1:0", func(ln net.Listener) error {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return err
+			}
+			go func() {
+				defer c.Close()
+				line, _ := bufio.NewReader(c).ReadString('\n')
+				c.Write([]byte(line))
+			}()
+		}
+	}, nil)
+
+	errChan := make(chan error, 1)
+	go func() { errChan <- s.Start() }()
+	for s.Probe(context.Background()) != nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	c, err := net.Dial("tcp", s.Address())
+	if err != nil {
+		t.Fatalf("expected Dial to pass, but got %v", err)
+	}
+	c.Write([]byte("ping\n"))
+	line, _ := bufio.NewReader(c).ReadString('\n')
+	c.Close()
+	if line != "ping\n" {
+		t.Errorf("expected an echo, but got %q", line)
+	}
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Errorf("expected Stop to pass, but got %v", err)
+	}
+	if err := <-errChan; err != nil {
+		t.Errorf("expected Start to return nil after Stop, but got %v", err)
+	}
+}
+
+func TestListenerServerStopBeforeStart(t *testing.T) {
+	var served int32
+	s := NewListenerServer("tcp", "127.0.0.1:0", func(ln net.Listener) error {
+		atomic.AddInt32(&served, 1)
+		_, err := ln.Accept()
+		return err
+	}, nil)
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("expected Stop to pass, but got %v", err)
+	}
+
+	errChan := make(chan error, 1)
+	go func() { errChan <- s.Start() }()
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Errorf("expected Start to return nil, but got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected Start to return after Stop")
+	}
+	if atomic.LoadInt32(&served) != 0 || s.Probe(context.Background()) == nil {
+		t.Errorf("expected the server not to serve after it was stopped")
+	}
+}
@@ -0,0 +1,130 @@
+package serverpool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+)
+
+// ListenerServer is a Server for anything which serves on a net.Listener, like raw TCP servers or gRPC servers:
+//
+//	g := grpc.NewServer()
+//	s := serverpool.NewListenerServer("tcp", ":9090", g.Serve, func(context.Context) error {
+//		g.GracefulStop()
+//		return nil
+//	})
+type ListenerServer struct {
+	Network string
+	Addr    string
+
+	serve    func(net.Listener) error
+	shutdown func(context.Context) error
+
+	mu       sync.Mutex
+	listener net.Listener
+	stopped  bool
+}
+
+// NewListenerServer returns a ListenerServer which listens on addr and passes the listener to serve.
+// Stop calls shutdown for a graceful shutdown, when shutdown is nil or doesn't return in time the listener
+// is closed instead.
+func NewListenerServer(network, addr string, serve func(net.Listener) error, shutdown func(context.Context) error) *ListenerServer {
+	return &ListenerServer{
+		Network:  network,
+		Addr:     addr,
+		serve:    serve,
+		shutdown: shutdown,
+	}
+}
+
+// Start listens on the address of the server and serves until the server is stopped. Once the server has been
+// stopped, Start returns immediately.
+func (s *ListenerServer) Start() error {
+	s.mu.Lock()
+	stopped := s.stopped
+	s.mu.Unlock()
+	if stopped {
+		return nil
+	}
+
+	ln, err := net.Listen(s.Network, s.Addr)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.stopped {
+		// Stopped while listening
+		s.mu.Unlock()
+		return ignoreClosed(ln.Close())
+	}
+	s.listener = ln
+	s.mu.Unlock()
+
+	err = s.serve(ln)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped && errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// Stop gracefully shuts down the server, a server which is stopped before it's started won't start anymore
+func (s *ListenerServer) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	ln := s.listener
+	s.mu.Unlock()
+
+	if ln == nil {
+		return nil
+	}
+	if s.shutdown == nil {
+		return ignoreClosed(ln.Close())
+	}
+
+	c := make(chan error, 1)
+	go func() {
+		c <- s.shutdown(ctx)
+	}()
+
+	select {
+	case err := <-c:
+		return err
+	case <-ctx.Done():
+		ln.Close()
+		return ctx.Err()
+	}
+}
+
+// Address returns the address the server listens on, once started this is the bound address
+func (s *ListenerServer) Address() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return s.listener.Addr().String()
+	}
+	return s.Addr
+}
+
+// Probe reports if the server is listening
+func (s *ListenerServer) Probe(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return ErrNotListening
+	}
+	return nil
+}
+
+func ignoreClosed(err error) error {
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package serverpool
+
+import (
+	"time"
+
+	"github.com/arjanvaneersel/kit/metrics"
+)
+
+// poolMetrics records the state of the pool, a nil *poolMetrics records nothing
+type poolMetrics struct {
+	up       *metrics.GaugeVec
+	restarts *metrics.CounterVec
+	failures *metrics.CounterVec
+	shutdown *metrics.HistogramVec
+	ready    *metrics.Gauge
+}
+
+func newPoolMetrics(r *metrics.Registry) *poolMetrics {
+	return &poolMetrics{
+		up:       r.GaugeVec("serverpool_server_up", "Whether a server is running (1) or not (0).", "server"),
+		restarts: r.CounterVec("serverpool_server_restarts_total", "Number of times a server was restarted.", "server"),
+		failures: r.CounterVec("serverpool_server_failures_total", "Number of times a server failed.", "server"),
+		shutdown: r.HistogramVec("serverpool_shutdown_duration_seconds", "Time it took to stop a server.", nil, "server"),
+		ready:    r.Gauge("serverpool_ready", "Whether the pool is ready (1) or not (0)."),
+	}
+}
+
+// Instrument records the metrics of the pool in r, pools created with New use metrics.Default.
+// A nil registry disables the metrics.
+func (p *Pool) Instrument(r *metrics.Registry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.metrics = nil
+	if r != nil {
+		p.metrics = newPoolMetrics(r)
+	}
+}
+
+func (p *Pool) poolMetrics() *poolMetrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.metrics
+}
+
+func (m *poolMetrics) setUp(name string, up bool) {
+	if m != nil {
+		m.up.With(name).Set(boolToFloat(up))
+	}
+}
+
+func (m *poolMetrics) restarted(name string) {
+	if m != nil {
+		m.restarts.With(name).Inc()
+	}
+}
+
+func (m *poolMetrics) failed(name string) {
+	if m != nil {
+		m.failures.With(name).Inc()
+	}
+}
+
+func (m *poolMetrics) stopped(name string, d time.Duration) {
+	if m != nil {
+		m.shutdown.With(name).Observe(d.Seconds())
+	}
+}
+
+func (m *poolMetrics) setReady(v bool) {
+	if m != nil {
+		m.ready.Set(boolToFloat(v))
+	}
+}
+
+func boolToFloat(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+package serverpool
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjanvaneersel/kit/metrics"
+)
+
+func TestPoolMetrics(t *testing.T) {
+	r := metrics.NewRegistry()
+	s := &flakyServer{testServer: newTestServer(nil), failures: 1}
+	p := New(testLogger, Item{Name: "worker", Server: s, Restart: RestartOptions{
+		Policy:         RestartOnFailure,
+		InitialBackoff: time.Millisecond,
+	}})
+	p.Instrument(r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Run(ctx); err != nil {
+		t.Fatalf("expected Run to pass, but got %v", err)
+	}
+
+	var b bytes.Buffer
+	r.WriteTo(&b)
+	for _, line := range []string{
+		`serverpool_server_restarts_total{server="worker"} 1`,
+		`serverpool_server_failures_total{server="worker"} 1`,
+		`serverpool_server_up{server="worker"}`,
+		`serverpool_shutdown_duration_seconds_count{server="worker"} 1`,
+		`serverpool_ready 0`,
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("expected %s in the metrics, but got\n%s", line, b.String())
+		}
+	}
+}
+
+func TestDiagnosticsMetrics(t *testing.T) {
+	d := NewDiagnosticsServer(0, testLogger)
+	w := httptest.NewRecorder()
+	d.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "go_goroutines") {
+		t.Errorf("expected the default metrics, but got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/arjanvaneersel/kit/logger"
+	"github.com/arjanvaneersel/kit/metrics"
 )
 
 // Server is an interface for servers which are to be used as a PoolItem
@@ -28,89 +30,255 @@ type Signaler interface {
 	Ready(bool)
 }
 
+// Reloader is used for servers which can reload their configuration, i.e. on SIGHUP
+type Reloader interface {
+	Reload() error
+}
+
+// Prober is used for servers which can report if they are ready to serve
+type Prober interface {
+	Probe(context.Context) error
+}
+
 // Item contains a name and a Server implementation
 type Item struct {
 	Name   string
 	Server Server
+
+	// DependsOn contains the names of the items which have to be ready before this item is started
+	DependsOn []string
+
+	// Probe reports if the item is ready, it defaults to the Probe method of servers which implement Prober.
+	// Items without a probe are ready as soon as they are started.
+	Probe func(context.Context) error
+
+	// StartTimeout overrides the StartTimeout of the pool for this item
+	StartTimeout time.Duration
+
+	// Restart configures if and how the item is restarted after Start returned
+	Restart RestartOptions
+
+	// StopTimeout overrides the StopTimeout of the pool for this item
+	StopTimeout time.Duration
+}
+
+// ServerError is the error of a server which failed
+type ServerError struct {
+	Name string
+	Err  error
+}
+
+// Error implements the error interface
+func (e *ServerError) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error of the server
+func (e *ServerError) Unwrap() error {
+	return e.Err
+}
+
+// PoolError contains the errors of all servers which failed
+type PoolError struct {
+	Errors []*ServerError
+}
+
+// Error implements the error interface
+func (e *PoolError) Error() string {
+	s := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		s[i] = err.Error()
+	}
+	return "servers failed: " + strings.Join(s, "; ")
+}
+
+// Failed returns the names of the servers which failed
+func (e *PoolError) Failed() []string {
+	names := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		names[i] = err.Name
+	}
+	return names
 }
 
 // Pool is used to manage Server implementationsl. Pool contains functions to gracefully take care of starting and stopping multiple servers
 type Pool struct {
-	mu           sync.Mutex
-	logger       logger.Logger
-	Items        []Item
-	readySignals []func(bool)
+	mu            sync.Mutex
+	logger        logger.Logger
+	Items         []Item
+	readySignals  []func(bool)
+	reloadHooks   []func() error
+	preStopHooks  []func(context.Context) error
+	postStopHooks []func(context.Context) error
+	started       []Item
+	cancelStart   context.CancelFunc
+	metrics       *poolMetrics
+
+	// Signals are the signals which stop the pool, SIGINT and SIGTERM by default
+	Signals []os.Signal
+
+	// ReloadSignals are the signals which trigger the reload hooks while running, SIGHUP by default
+	ReloadSignals []os.Signal
+
+	// StartTimeout is the time a server gets to become ready after it's started, 30 seconds by default
+	StartTimeout time.Duration
+
+	// ProbeInterval is the interval between readiness probes, 1 second by default
+	ProbeInterval time.Duration
+
+	// StopTimeout is the time a server gets to shut down gracefully, 30 seconds by default
+	StopTimeout time.Duration
+
+	// ShutdownTimeout is the deadline for the whole shutdown, including the drain delay, 1 minute by default
+	ShutdownTimeout time.Duration
+
+	// DrainDelay is the time between marking the pool as not ready and stopping the servers, which gives
+	// load balancers the time to take the pool out of rotation
+	DrainDelay time.Duration
+
+	// ParallelShutdown stops all servers at once instead of one by one in reverse start order
+	ParallelShutdown bool
+}
+
+// OnReload registers a hook which is called when a reload signal is received. Servers which implement Reloader
+// are reloaded automatically.
+func (p *Pool) OnReload(f func() error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reloadHooks = append(p.reloadHooks, f)
 }
 
 // Start will start all servers in the pool. It returns a channel for operating system signals and errors
 func (p *Pool) Start() (chan os.Signal, chan error) {
-	// Create the error channel
-	errChan := make(chan error)
+	// Create the signal channel and subscribe to the stop signals, the channel is buffered as the
+	// signal package doesn't block when delivering signals
+	sigChan := make(chan os.Signal, 1)
+	if len(p.Signals) > 0 {
+		// Notify without signals would relay all of them
+		signal.Notify(sigChan, p.Signals...)
+	}
 
-	// Create the signal channel and subscribe to SIGINT and SIGTERM signals
-	// TODO: More flexibility on signal subscriptions
-	sigChan := make(chan os.Signal)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	errChan, err := p.start(context.Background())
+	if err != nil {
+		errChan = make(chan error, 1)
+		errChan <- err
+	}
+	return sigChan, errChan
+}
 
-	// Loop over all items
-	for _, i := range p.Items {
-		// Launch the item in a go routine
-		go func(i Item, errChan chan error) {
-			p.logger.Log(logger.INFO, "starting", i.Name, "on", i.Server.Address())
-			if err := i.Server.Start(); err != nil {
-				errChan <- err
-			}
-		}(i, errChan)
+// Run starts all servers and blocks until ctx is cancelled, a stop signal is received or a server fails.
+// Afterwards all servers are stopped. Run returns a *PoolError when one or more servers failed.
+func (p *Pool) Run(ctx context.Context) error {
+	sigChan := make(chan os.Signal, 1)
+	signals := append(append([]os.Signal{}, p.Signals...), p.ReloadSignals...)
+	if len(signals) > 0 {
+		signal.Notify(sigChan, signals...)
+		defer signal.Stop(sigChan)
+	}
 
-		// Check if the server has a Ready method, if so
+	errChan, err := p.start(ctx)
+	if err != nil {
+		return err
+	}
 
-		if d, ok := i.Server.(Signaler); ok {
-			p.mu.Lock()
-			defer p.mu.Unlock()
-			p.readySignals = append(p.readySignals, d.Ready)
+	var failed []*ServerError
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Log(logger.INFO, "context done", ctx.Err())
+			break loop
+		case sig := <-sigChan:
+			if containsSignal(p.ReloadSignals, sig) {
+				p.logger.Log(logger.INFO, "signal", sig, "reloading")
+				p.reload()
+				continue
+			}
+			p.logger.Log(logger.INFO, "signal", sig, "stopping")
+			break loop
+		case err := <-errChan:
+			p.logger.Log(logger.ERROR, "server failed", err)
+			failed = append(failed, err.(*ServerError))
+			break loop
 		}
 	}
 
-	return sigChan, errChan
-}
+	if res := p.Stop(); len(res.Failed) > 0 {
+		failed = append(failed, res.Failed...)
+	}
 
-// Ready is used to signal all relevant items that the pool is ready
-func (p *Pool) Ready(v bool) {
-	for _, f := range p.readySignals {
-		f(true)
+	// Collect the errors of servers which failed while stopping the pool
+drain:
+	for {
+		select {
+		case err := <-errChan:
+			failed = append(failed, err.(*ServerError))
+		default:
+			break drain
+		}
+	}
+
+	if len(failed) > 0 {
+		return &PoolError{Errors: failed}
 	}
+	return nil
 }
 
-// Stop loops over all items and executes a graceful shutdown with a timeout of 1 minute
-// TODO: More flexible timeout
-func (p *Pool) Stop() {
-	p.Ready(false)
+// reload calls the reload hooks and reloads all servers which implement Reloader
+func (p *Pool) reload() {
 	for _, i := range p.Items {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+		if r, ok := i.Server.(Reloader); ok {
+			if err := r.Reload(); err != nil {
+				p.logger.Log(logger.ERROR, "reloading", i.Name, "error", err)
+			}
+		}
+	}
 
-		c := make(chan error, 1)
-		go func() {
-			p.logger.Log(logger.INFO, "stopping", i.Name, "on", i.Server.Address())
-			c <- i.Server.Stop(ctx)
-		}()
+	p.mu.Lock()
+	hooks := append([]func() error{}, p.reloadHooks...)
+	p.mu.Unlock()
 
-		select {
-		case <-ctx.Done():
-			if err := ctx.Err(); err != nil {
-				p.logger.Log(logger.ERROR, "stopping", i.Name, "error", err)
-			}
-		case err := <-c:
-			if err != nil {
-				p.logger.Log(logger.ERROR, "stopping", i.Name, "error", err)
-			}
+	for _, f := range hooks {
+		if err := f(); err != nil {
+			p.logger.Log(logger.ERROR, "reload hook", "error", err)
+		}
+	}
+}
+
+func containsSignal(signals []os.Signal, sig os.Signal) bool {
+	for _, s := range signals {
+		if s == sig {
+			return true
 		}
 	}
+	return false
+}
+
+// Ready is used to signal all relevant items that the pool is ready
+func (p *Pool) Ready(v bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ready(v)
+}
+
+// ready signals all relevant items, p.mu must be held
+func (p *Pool) ready(v bool) {
+	p.metrics.setReady(v)
+	for _, f := range p.readySignals {
+		f(v)
+	}
 }
 
 func New(l logger.Logger, items ...Item) *Pool {
 	return &Pool{
-		logger: l,
-		Items:  items,
+		logger:          l,
+		Items:           items,
+		Signals:         []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		ReloadSignals:   []os.Signal{syscall.SIGHUP},
+		StartTimeout:    30 * time.Second,
+		ProbeInterval:   time.Second,
+		StopTimeout:     30 * time.Second,
+		ShutdownTimeout: time.Minute,
+		metrics:         newPoolMetrics(metrics.Default),
 	}
 }
@@ -0,0 +1,107 @@
+package serverpool
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/arjanvaneersel/kit/logger"
+)
+
+var testLogger = logger.NewStdLog(logger.DEBUG, log.New(ioutil.Discard, "", 0))
+
+// testServer blocks in Start until it's stopped, or fails immediately when err is set
+type testServer struct {
+	err      error
+	done     chan struct{}
+	stopped  int32
+	reloaded int32
+}
+
+func newTestServer(err error) *testServer {
+	return &testServer{err: err, done: make(chan struct{})}
+}
+
+func (s *testServer) Start() error {
+	if s.err != nil {
+		return s.err
+	}
+	<-s.done
+	return nil
+}
+
+func (s *testServer) Stop(context.Context) error {
+	if atomic.CompareAndSwapInt32(&s.stopped, 0, 1) {
+		close(s.done)
+	}
+	return nil
+}
+
+func (s *testServer) Address() string {
+	return "test"
+}
+
+func (s *testServer) Reload() error {
+	atomic.AddInt32(&s.reloaded, 1)
+	return nil
+}
+
+func TestRunContext(t *testing.T) {
+	s := newTestServer(nil)
+	p := New(testLogger, Item{Name: "test", Server: s})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	if err := p.Run(ctx); err != nil {
+		t.Errorf("expected Run to pass, but got %v", err)
+	}
+	if atomic.LoadInt32(&s.stopped) != 1 {
+		t.Errorf("expected the server to be stopped")
+	}
+}
+
+func TestStartCancelledContext(t *testing.T) {
+	p := New(testLogger, Item{Name: "test", Server: newTestServer(nil)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.start(ctx); err != nil {
+		t.Fatalf("expected start to pass, but got %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.started) != 0 {
+		t.Errorf("expected no servers to be started for a cancelled context, but got %d", len(p.started))
+	}
+}
+
+func TestRunServerFailure(t *testing.T) {
+	errListen := errors.New("address already in use")
+	ok := newTestServer(nil)
+	p := New(testLogger, Item{Name: "ok", Server: ok}, Item{Name: "api", Server: newTestServer(errListen)})
+
+	err := p.Run(context.Background())
+	var pe *PoolError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected a *PoolError, but got %v", err)
+	}
+	if failed := pe.Failed(); len(failed) != 1 || failed[0] != "api" {
+		t.Errorf("expected api to fail, but got %v", failed)
+	}
+	if !errors.Is(pe.Errors[0], errListen) {
+		t.Errorf("expected the server error to wrap %v, but got %v", errListen, pe.Errors[0])
+	}
+	if atomic.LoadInt32(&ok.stopped) != 1 {
+		t.Errorf("expected the remaining server to be stopped")
+	}
+}
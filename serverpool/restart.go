@@ -0,0 +1,144 @@
+package serverpool
+
+import (
+	"errors"
+	"time"
+)
+
+// RestartPolicy determines when an item is restarted after its Start method returned
+type RestartPolicy int
+
+const (
+	// RestartNever never restarts an item, a failing item stops the pool
+	RestartNever RestartPolicy = iota
+
+	// RestartOnFailure restarts an item when Start returned an error
+	RestartOnFailure
+
+	// RestartAlways restarts an item whenever Start returned, unless the pool is stopping
+	RestartAlways
+)
+
+// String implements the stringer interface
+func (r RestartPolicy) String() string {
+	switch r {
+	case RestartNever:
+		return "never"
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartAlways:
+		return "always"
+	}
+	return "unknown"
+}
+
+var (
+	ErrMaxRestarts = errors.New("maximum number of restarts reached")
+	ErrCrashLoop   = errors.New("server is crash looping")
+)
+
+// Default restart options
+const (
+	DefaultInitialBackoff    = time.Second
+	DefaultMaxBackoff        = time.Minute
+	DefaultCrashLoopRestarts = 5
+	DefaultCrashLoopWindow   = time.Minute
+)
+
+// RestartOptions configure how an item is supervised. Zero values use the defaults.
+type RestartOptions struct {
+	Policy RestartPolicy
+
+	// MaxRestarts limits the total number of restarts, 0 means no limit
+	MaxRestarts int
+
+	// InitialBackoff is the delay before the first restart, it's doubled for every consecutive restart
+	InitialBackoff time.Duration
+
+	// MaxBackoff limits the delay between restarts. When an item ran longer than MaxBackoff before it
+	// exited, the delay is reset to InitialBackoff.
+	MaxBackoff time.Duration
+
+	// CrashLoopRestarts restarts within CrashLoopWindow trip the crash-loop breaker, which gives up on the
+	// item. A negative value disables the breaker.
+	CrashLoopRestarts int
+	CrashLoopWindow   time.Duration
+}
+
+// shouldRestart reports if an item which exited with err has to be restarted
+func (o RestartOptions) shouldRestart(err error) bool {
+	switch o.Policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	}
+	return false
+}
+
+func (o RestartOptions) initialBackoff() time.Duration {
+	if o.InitialBackoff > 0 {
+		return o.InitialBackoff
+	}
+	return DefaultInitialBackoff
+}
+
+func (o RestartOptions) maxBackoff() time.Duration {
+	if o.MaxBackoff > 0 {
+		return o.MaxBackoff
+	}
+	return DefaultMaxBackoff
+}
+
+func (o RestartOptions) crashLoop() (int, time.Duration) {
+	n, w := o.CrashLoopRestarts, o.CrashLoopWindow
+	if n == 0 {
+		n = DefaultCrashLoopRestarts
+	}
+	if w <= 0 {
+		w = DefaultCrashLoopWindow
+	}
+	return n, w
+}
+
+// supervisor keeps track of the restarts of a single item
+type supervisor struct {
+	RestartOptions
+	restarts int
+	backoff  time.Duration
+	recent   []time.Time
+}
+
+// next registers an exit of an item which ran since started and returns the delay before the restart,
+// or an error when the item has to be given up on
+func (s *supervisor) next(started time.Time) (time.Duration, error) {
+	now := time.Now()
+	s.restarts++
+	if s.MaxRestarts > 0 && s.restarts > s.MaxRestarts {
+		return 0, ErrMaxRestarts
+	}
+
+	if n, w := s.crashLoop(); n > 0 {
+		recent := s.recent[:0]
+		for _, t := range s.recent {
+			if now.Sub(t) < w {
+				recent = append(recent, t)
+			}
+		}
+		s.recent = append(recent, now)
+		if len(s.recent) > n {
+			return 0, ErrCrashLoop
+		}
+	}
+
+	switch {
+	case s.backoff == 0 || now.Sub(started) > s.maxBackoff():
+		s.backoff = s.initialBackoff()
+	default:
+		s.backoff *= 2
+		if s.backoff > s.maxBackoff() {
+			s.backoff = s.maxBackoff()
+		}
+	}
+	return s.backoff, nil
+}
@@ -0,0 +1,91 @@
+package serverpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures starts and blocks afterwards
+type flakyServer struct {
+	*testServer
+	failures int32
+	starts   int32
+}
+
+func (s *flakyServer) Start() error {
+	if atomic.AddInt32(&s.starts, 1) <= s.failures {
+		return errors.New("connection refused")
+	}
+	return s.testServer.Start()
+}
+
+func TestRestartOnFailure(t *testing.T) {
+	s := &flakyServer{testServer: newTestServer(nil), failures: 2}
+	p := New(testLogger, Item{Name: "worker", Server: s, Restart: RestartOptions{
+		Policy:         RestartOnFailure,
+		InitialBackoff: time.Millisecond,
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := p.Run(ctx); err != nil {
+		t.Errorf("expected Run to pass, but got %v", err)
+	}
+	if starts := atomic.LoadInt32(&s.starts); starts != 3 {
+		t.Errorf("expected 3 starts, but got %d", starts)
+	}
+}
+
+func TestRestartLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		options RestartOptions
+		err     error
+	}{
+		{"never", RestartOptions{}, nil},
+		{"max restarts", RestartOptions{Policy: RestartOnFailure, MaxRestarts: 3, CrashLoopRestarts: -1}, ErrMaxRestarts},
+		{"crash loop", RestartOptions{Policy: RestartAlways, CrashLoopRestarts: 2}, ErrCrashLoop},
+	}
+
+	for _, tt := range tests {
+		tt.options.InitialBackoff = time.Millisecond
+		s := &flakyServer{testServer: newTestServer(nil), failures: 10}
+		p := New(testLogger, Item{Name: "worker", Server: s, Restart: tt.options})
+
+		err := p.Run(context.Background())
+		var pe *PoolError
+		if !errors.As(err, &pe) || pe.Failed()[0] != "worker" {
+			t.Fatalf("%s: expected worker to fail, but got %v", tt.name, err)
+		}
+		if tt.err != nil && !errors.Is(pe.Errors[0], tt.err) {
+			t.Errorf("%s: expected %v, but got %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	s := supervisor{RestartOptions: RestartOptions{
+		InitialBackoff:    10 * time.Millisecond,
+		MaxBackoff:        50 * time.Millisecond,
+		CrashLoopRestarts: -1,
+	}}
+
+	now := time.Now()
+	for n, expected := range []time.Duration{10, 20, 40, 50, 50} {
+		d, err := s.next(now)
+		if err != nil {
+			t.Fatalf("expected next to pass, but got %v", err)
+		}
+		if d != expected*time.Millisecond {
+			t.Errorf("restart %d: expected a backoff of %v, but got %v", n+1, expected*time.Millisecond, d)
+		}
+	}
+
+	// An item which ran longer than the maximum backoff starts over
+	if d, _ := s.next(now.Add(-time.Second)); d != 10*time.Millisecond {
+		t.Errorf("expected the backoff to be reset, but got %v", d)
+	}
+}
@@ -0,0 +1,180 @@
+package serverpool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/arjanvaneersel/kit/logger"
+)
+
+// ShutdownResult describes the outcome of stopping the pool
+type ShutdownResult struct {
+	// Stopped contains the names of the servers which stopped cleanly
+	Stopped []string
+
+	// Failed contains the servers which returned an error or didn't stop in time
+	Failed []*ServerError
+
+	// Duration is the total time the shutdown took
+	Duration time.Duration
+}
+
+// Err returns a *PoolError when one or more servers failed to stop, otherwise nil
+func (r *ShutdownResult) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	return &PoolError{Errors: r.Failed}
+}
+
+// OnPreStop registers a hook which is called before the servers are stopped, after the pool is marked as not ready
+func (p *Pool) OnPreStop(f func(context.Context) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.preStopHooks = append(p.preStopHooks, f)
+}
+
+// OnPostStop registers a hook which is called after all servers are stopped, i.e. to close database connections.
+// The logger of the pool is still open, it's closed after the hooks.
+//
+//	p.OnPostStop(func(context.Context) error { return db.Close() })
+func (p *Pool) OnPostStop(f func(context.Context) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.postStopHooks = append(p.postStopHooks, f)
+}
+
+// Stop gracefully shuts down all servers within the ShutdownTimeout of the pool
+func (p *Pool) Stop() *ShutdownResult {
+	ctx := context.Background()
+	if p.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.ShutdownTimeout)
+		defer cancel()
+	}
+	return p.Shutdown(ctx)
+}
+
+// Shutdown marks the pool as not ready, runs the pre-stop hooks, waits for the drain delay and then stops
+// all servers. Every server gets its StopTimeout, but never more than the deadline of ctx. Afterwards the
+// logger of the pool is flushed when it implements logger.Flusher and the post-stop hooks run. Finally the
+// logger is closed when it implements io.Closer.
+func (p *Pool) Shutdown(ctx context.Context) *ShutdownResult {
+	start := time.Now()
+
+	// Cancel the startup of items which are still waiting for their dependencies
+	p.mu.Lock()
+	if p.cancelStart != nil {
+		p.cancelStart()
+	}
+	p.ready(false)
+	hooks := append([]func(context.Context) error{}, p.preStopHooks...)
+	items := p.started
+	if items == nil {
+		items = p.Items
+	}
+	p.mu.Unlock()
+
+	for _, f := range hooks {
+		if err := f(ctx); err != nil {
+			p.logger.Log(logger.ERROR, "pre-stop hook", "error", err)
+		}
+	}
+
+	if p.DrainDelay > 0 {
+		p.logger.Log(logger.INFO, "draining", p.DrainDelay)
+		t := time.NewTimer(p.DrainDelay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+		}
+	}
+
+	errs := make([]error, len(items))
+	if p.ParallelShutdown {
+		var wg sync.WaitGroup
+		for n, i := range items {
+			wg.Add(1)
+			go func(n int, i Item) {
+				defer wg.Done()
+				errs[n] = p.stopItem(ctx, i)
+			}(n, i)
+		}
+		wg.Wait()
+	} else {
+		// Stop in reverse start order, so servers are stopped before the servers they depend on
+		for n := len(items) - 1; n >= 0; n-- {
+			errs[n] = p.stopItem(ctx, items[n])
+		}
+	}
+
+	var res ShutdownResult
+	for n, i := range items {
+		if errs[n] != nil {
+			res.Failed = append(res.Failed, &ServerError{Name: i.Name, Err: fmt.Errorf("stop: %w", errs[n])})
+			continue
+		}
+		res.Stopped = append(res.Stopped, i.Name)
+	}
+
+	// Flush before the post-stop hooks, which may close the sinks of the logger
+	if f, ok := p.logger.(logger.Flusher); ok {
+		f.Flush()
+	}
+
+	p.mu.Lock()
+	hooks = append([]func(context.Context) error{}, p.postStopHooks...)
+	p.mu.Unlock()
+	for _, f := range hooks {
+		if err := f(ctx); err != nil {
+			p.logger.Log(logger.ERROR, "post-stop hook", "error", err)
+		}
+	}
+
+	// Closing the logger is the last step, nothing is logged afterwards
+	if c, ok := p.logger.(io.Closer); ok {
+		c.Close()
+	}
+	res.Duration = time.Since(start)
+	return &res
+}
+
+// stopItem gracefully stops a single item within its stop timeout
+func (p *Pool) stopItem(ctx context.Context, i Item) error {
+	timeout := i.StopTimeout
+	if timeout == 0 {
+		timeout = p.StopTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	p.logger.Log(logger.INFO, "stopping", i.Name, "on", i.Server.Address())
+
+	c := make(chan error, 1)
+	go func() {
+		c <- i.Server.Stop(ctx)
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case err = <-c:
+	}
+	p.poolMetrics().stopped(i.Name, time.Since(start))
+
+	if err != nil {
+		p.logger.Log(logger.ERROR, "stopping", i.Name, "error", err)
+		return err
+	}
+	p.logger.Log(logger.INFO, "stopped", i.Name, "duration", time.Since(start))
+	return nil
+}
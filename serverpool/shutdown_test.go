@@ -0,0 +1,191 @@
+package serverpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/arjanvaneersel/kit/logger"
+)
+
+// orderServer records the order in which servers are stopped
+type orderServer struct {
+	name  string
+	mu    *sync.Mutex
+	order *[]string
+	delay time.Duration
+}
+
+func (s *orderServer) Start() error {
+	return nil
+}
+
+func (s *orderServer) Stop(ctx context.Context) error {
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	*s.order = append(*s.order, s.name)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *orderServer) Address() string {
+	return s.name
+}
+
+func orderItems(delays ...time.Duration) ([]Item, *[]string) {
+	var (
+		mu    sync.Mutex
+		order []string
+		items []Item
+	)
+	for n, d := range delays {
+		name := string(rune('a' + n))
+		items = append(items, Item{Name: name, Server: &orderServer{name: name, mu: &mu, order: &order, delay: d}})
+	}
+	return items, &order
+}
+
+// waitStarted waits until n items of the pool are started
+func waitStarted(t *testing.T, p *Pool, n int) {
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		p.mu.Lock()
+		started := len(p.started)
+		p.mu.Unlock()
+		if started == n {
+			return
+		}
+	}
+	t.Fatalf("expected %d items to be started", n)
+}
+
+func TestShutdownReverseOrder(t *testing.T) {
+	items, order := orderItems(0, 0, 0)
+	items[1].DependsOn = []string{"a"}
+	items[2].DependsOn = []string{"b"}
+	p := New(testLogger, items...)
+	if _, err := p.start(context.Background()); err != nil {
+		t.Fatalf("expected start to pass, but got %v", err)
+	}
+	waitStarted(t, p, len(items))
+
+	res := p.Stop()
+	if err := res.Err(); err != nil {
+		t.Fatalf("expected Stop to pass, but got %v", err)
+	}
+	if got := *order; len(got) != 3 || got[0] != "c" || got[1] != "b" || got[2] != "a" {
+		t.Errorf("expected servers to stop in reverse order, but got %v", got)
+	}
+	if len(res.Stopped) != 3 {
+		t.Errorf("expected 3 stopped servers, but got %v", res.Stopped)
+	}
+}
+
+func TestShutdownParallel(t *testing.T) {
+	items, _ := orderItems(50*time.Millisecond, 50*time.Millisecond, 50*time.Millisecond)
+	p := New(testLogger, items...)
+	p.ParallelShutdown = true
+
+	res := p.Stop()
+	if err := res.Err(); err != nil {
+		t.Fatalf("expected Stop to pass, but got %v", err)
+	}
+	if res.Duration >= 140*time.Millisecond {
+		t.Errorf("expected a parallel shutdown, but it took %v", res.Duration)
+	}
+}
+
+func TestShutdownTimeouts(t *testing.T) {
+	items, _ := orderItems(0, time.Second, 0)
+	items[1].StopTimeout = 20 * time.Millisecond
+	p := New(testLogger, items...)
+
+	res := p.Stop()
+	var pe *PoolError
+	if err := res.Err(); !errors.As(err, &pe) || len(pe.Errors) != 1 || pe.Errors[0].Name != "b" {
+		t.Fatalf("expected b to fail, but got %v", err)
+	}
+	if !errors.Is(res.Failed[0], context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, but got %v", res.Failed[0])
+	}
+	if len(res.Stopped) != 2 {
+		t.Errorf("expected a and c to stop cleanly, but got %v", res.Stopped)
+	}
+
+	// The overall deadline limits the drain delay and all servers
+	items, _ = orderItems(time.Second)
+	p = New(testLogger, items...)
+	p.DrainDelay = time.Second
+	p.ShutdownTimeout = 50 * time.Millisecond
+	if res := p.Stop(); len(res.Failed) != 1 || res.Duration > 500*time.Millisecond {
+		t.Errorf("expected the shutdown to be cut off at its deadline, but got %+v", res)
+	}
+}
+
+func TestPreStop(t *testing.T) {
+	items, order := orderItems(0)
+	p := New(testLogger, items...)
+	p.DrainDelay = 30 * time.Millisecond
+
+	var called time.Time
+	p.OnPreStop(func(context.Context) error {
+		called = time.Now()
+		if len(*order) != 0 {
+			t.Errorf("expected the pre-stop hook to run before the servers are stopped")
+		}
+		return nil
+	})
+
+	res := p.Stop()
+	if called.IsZero() {
+		t.Fatalf("expected the pre-stop hook to be called")
+	}
+	if res.Duration < p.DrainDelay {
+		t.Errorf("expected the shutdown to wait for the drain delay, but it took %v", res.Duration)
+	}
+}
+
+// flushLogger records whether it was flushed and closed
+type flushLogger struct {
+	logger.Logger
+	flushed, closed int32
+}
+
+func (l *flushLogger) Flush() error {
+	atomic.AddInt32(&l.flushed, 1)
+	return nil
+}
+
+func (l *flushLogger) Close() error {
+	atomic.AddInt32(&l.closed, 1)
+	return nil
+}
+
+func TestPostStop(t *testing.T) {
+	items, order := orderItems(0, 0)
+	l := &flushLogger{Logger: testLogger}
+	p := New(l, items...)
+
+	var stopped, flushed, closed int32
+	p.OnPostStop(func(context.Context) error {
+		stopped, flushed, closed = int32(len(*order)), atomic.LoadInt32(&l.flushed), atomic.LoadInt32(&l.closed)
+		return errors.New("close failed")
+	})
+
+	p.Stop()
+	if stopped != 2 {
+		t.Errorf("expected the post-stop hook to run after the servers are stopped, but %d were stopped", stopped)
+	}
+	if flushed != 1 {
+		t.Errorf("expected the logger to be flushed before the post-stop hook")
+	}
+	if closed != 0 || atomic.LoadInt32(&l.closed) != 1 {
+		t.Errorf("expected the logger to be closed after the post-stop hook")
+	}
+}
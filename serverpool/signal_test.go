@@ -0,0 +1,61 @@
+//go:build !windows
+// +build !windows
+
+package serverpool
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunSignals(t *testing.T) {
+	s := newTestServer(nil)
+	p := New(testLogger, Item{Name: "test", Server: s})
+	p.Signals = []os.Signal{syscall.SIGUSR1}
+	p.ReloadSignals = []os.Signal{syscall.SIGUSR2}
+
+	var hooks int32
+	p.OnReload(func() error {
+		atomic.AddInt32(&hooks, 1)
+		return nil
+	})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+		time.Sleep(20 * time.Millisecond)
+		syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	}()
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Errorf("expected Run to pass, but got %v", err)
+	}
+	if atomic.LoadInt32(&s.reloaded) != 1 || atomic.LoadInt32(&hooks) != 1 {
+		t.Errorf("expected the server and hook to be reloaded once, but got %d and %d", s.reloaded, hooks)
+	}
+	if atomic.LoadInt32(&s.stopped) != 1 {
+		t.Errorf("expected the server to be stopped")
+	}
+}
+
+func TestStartWithoutSignals(t *testing.T) {
+	p := New(testLogger, Item{Name: "test", Server: newTestServer(nil)})
+	p.Signals = nil
+
+	sigChan, _ := p.Start()
+	defer signal.Stop(sigChan)
+	defer p.Stop()
+
+	// SIGURG is ignored by default, it's only relayed when the channel subscribed to all signals
+	syscall.Kill(os.Getpid(), syscall.SIGURG)
+	select {
+	case sig := <-sigChan:
+		t.Errorf("expected no signals, but got %v", sig)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,333 @@
+package serverpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/arjanvaneersel/kit/logger"
+)
+
+var (
+	ErrDuplicateItem     = errors.New("duplicate item name")
+	ErrUnknownDependency = errors.New("unknown dependency")
+	ErrDependencyCycle   = errors.New("dependency cycle")
+	ErrStartTimeout      = errors.New("server didn't become ready in time")
+	errExitedBeforeReady = errors.New("server exited before it was ready")
+)
+
+const defaultProbeInterval = time.Second
+
+// order returns the items in topological order, with dependencies before the items depending on them.
+// Items which don't depend on each other keep their original order.
+func order(items []Item) ([]Item, error) {
+	index := make(map[string]int, len(items))
+	for n, i := range items {
+		if _, ok := index[i.Name]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateItem, i.Name)
+		}
+		index[i.Name] = n
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(items))
+	sorted := make([]Item, 0, len(items))
+
+	var visit func(n int, path []string) error
+	visit = func(n int, path []string) error {
+		path = append(path, items[n].Name)
+		switch state[n] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(path, " -> "))
+		}
+
+		state[n] = visiting
+		for _, d := range items[n].DependsOn {
+			m, ok := index[d]
+			if !ok {
+				return fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, items[n].Name, d)
+			}
+			if err := visit(m, path); err != nil {
+				return err
+			}
+		}
+		state[n] = visited
+		sorted = append(sorted, items[n])
+		return nil
+	}
+
+	for n := range items {
+		if err := visit(n, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// probe returns the readiness probe of an item, or nil when it doesn't have one
+func probe(i Item) func(context.Context) error {
+	if i.Probe != nil {
+		return i.Probe
+	}
+	if p, ok := i.Server.(Prober); ok {
+		return p.Probe
+	}
+	return nil
+}
+
+func (p *Pool) probeInterval() time.Duration {
+	if p.ProbeInterval > 0 {
+		return p.ProbeInterval
+	}
+	return defaultProbeInterval
+}
+
+// check runs a single probe, which gets at most one probe interval to complete
+func (p *Pool) check(ctx context.Context, f func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, p.probeInterval())
+	defer cancel()
+	return f(ctx)
+}
+
+// start launches all items in dependency order and returns a channel which receives a *ServerError for every
+// server which fails. Every item is started as soon as its dependencies are ready. Items aren't started or
+// restarted anymore once ctx is cancelled or the pool is stopped.
+func (p *Pool) start(ctx context.Context) (chan error, error) {
+	items, err := order(p.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	// The error channel can hold an error of every item, so failing servers never block
+	errChan := make(chan error, len(items))
+
+	ready := make(map[string]chan struct{}, len(items))
+	for _, i := range items {
+		ready[i.Name] = make(chan struct{})
+	}
+
+	p.mu.Lock()
+	p.cancelStart = cancel
+	p.started = []Item{}
+	p.readySignals = nil
+	for _, i := range items {
+		// Check if the server has a Ready method, if so register it
+		if d, ok := i.Server.(Signaler); ok {
+			p.readySignals = append(p.readySignals, d.Ready)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, i := range items {
+		go p.startItem(ctx, i, ready, errChan)
+	}
+	go p.monitor(ctx, items, ready)
+
+	return errChan, nil
+}
+
+// startItem waits for the dependencies of i, starts it, waits until it's ready and supervises it according to
+// its restart options
+func (p *Pool) startItem(ctx context.Context, i Item, ready map[string]chan struct{}, errChan chan<- error) {
+	for _, d := range i.DependsOn {
+		select {
+		case <-ready[d]:
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	if !p.launch(ctx, i, true) {
+		return
+	}
+
+	s := supervisor{RestartOptions: i.Restart}
+	m := p.poolMetrics()
+	isReady := false
+	for {
+		started := time.Now()
+		exited := make(chan struct{})
+		var err error
+		go func() {
+			defer close(exited)
+			p.logger.Log(logger.INFO, "starting", i.Name, "on", i.Server.Address())
+			m.setUp(i.Name, true)
+			if err = i.Server.Start(); errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			}
+			m.setUp(i.Name, false)
+			if err != nil {
+				m.failed(i.Name)
+			}
+		}()
+
+		if !isReady {
+			switch werr := p.waitReady(ctx, i, exited); werr {
+			case nil:
+				p.logger.Log(logger.INFO, "ready", i.Name)
+				close(ready[i.Name])
+				isReady = true
+			case ErrStartTimeout:
+				p.logger.Log(logger.ERROR, "starting", i.Name, "error", werr)
+				errChan <- &ServerError{Name: i.Name, Err: werr}
+				return
+			}
+		}
+		<-exited
+
+		// Items which exit because the pool is stopping are never restarted
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !s.shouldRestart(err) {
+			if err != nil {
+				errChan <- &ServerError{Name: i.Name, Err: err}
+			} else {
+				p.logger.Log(logger.INFO, "exited", i.Name)
+			}
+			return
+		}
+
+		backoff, serr := s.next(started)
+		if serr != nil {
+			p.logger.Log(logger.ERROR, "giving up on", i.Name, "restarts", s.restarts-1, "error", serr)
+			if err == nil {
+				err = errors.New("exited")
+			}
+			errChan <- &ServerError{Name: i.Name, Err: fmt.Errorf("%w: %v", serr, err)}
+			return
+		}
+
+		p.logger.Log(logger.WARN, "restarting", i.Name, "in", backoff, "restart", s.restarts, "error", err)
+		m.restarted(i.Name)
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+
+		if !p.launch(ctx, i, false) {
+			return
+		}
+	}
+}
+
+// launch reports if i may be started, which isn't the case once the pool is stopping. The first launch
+// registers the item as started, so it's stopped by the pool.
+func (p *Pool) launch(ctx context.Context, i Item, first bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return false
+	}
+	if first {
+		p.started = append(p.started, i)
+	}
+	return true
+}
+
+// waitReady probes i until it's ready, its start timeout expires or it exits
+func (p *Pool) waitReady(ctx context.Context, i Item, exited <-chan struct{}) error {
+	f := probe(i)
+	if f == nil {
+		return nil
+	}
+
+	timeout := i.StartTimeout
+	if timeout == 0 {
+		timeout = p.StartTimeout
+	}
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		deadline = t.C
+	}
+
+	t := time.NewTicker(p.probeInterval())
+	defer t.Stop()
+
+	for {
+		if err := p.check(ctx, f); err == nil {
+			return nil
+		}
+
+		select {
+		case <-t.C:
+		case <-deadline:
+			return ErrStartTimeout
+		case <-exited:
+			return errExitedBeforeReady
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// monitor marks the pool as ready once all items are ready and keeps probing the items afterwards, so the
+// readiness of the pool follows the readiness of its items
+func (p *Pool) monitor(ctx context.Context, items []Item, ready map[string]chan struct{}) {
+	for _, i := range items {
+		select {
+		case <-ready[i.Name]:
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	p.logger.Log(logger.INFO, "pool ready")
+	p.setReady(ctx, true)
+
+	t := time.NewTicker(p.probeInterval())
+	defer t.Stop()
+
+	state := true
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+
+		v := true
+		for _, i := range items {
+			if f := probe(i); f != nil {
+				if err := p.check(ctx, f); err != nil {
+					p.logger.Log(logger.WARN, "not ready", i.Name, "error", err)
+					v = false
+					break
+				}
+			}
+		}
+
+		if v != state {
+			p.setReady(ctx, v)
+			state = v
+		}
+	}
+}
+
+// setReady signals the readiness of the pool, unless the pool is stopping
+func (p *Pool) setReady(ctx context.Context, v bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ctx.Err() == nil {
+		p.ready(v)
+	}
+}
@@ -0,0 +1,152 @@
+package serverpool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOrder(t *testing.T) {
+	s := newTestServer(nil)
+	items := []Item{
+		{Name: "api", Server: s, DependsOn: []string{"grpc", "db"}},
+		{Name: "grpc", Server: s, DependsOn: []string{"db"}},
+		{Name: "db", Server: s},
+		{Name: "diagnostics", Server: s},
+	}
+
+	sorted, err := order(items)
+	if err != nil {
+		t.Fatalf("expected order to pass, but got %v", err)
+	}
+	var names []string
+	for _, i := range sorted {
+		names = append(names, i.Name)
+	}
+	if len(names) != 4 || names[0] != "db" || names[1] != "grpc" || names[2] != "api" || names[3] != "diagnostics" {
+		t.Errorf("unexpected order %v", names)
+	}
+
+	tests := []struct {
+		name  string
+		items []Item
+		err   error
+	}{
+		{"cycle", []Item{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"a"}}}, ErrDependencyCycle},
+		{"self", []Item{{Name: "a", DependsOn: []string{"a"}}}, ErrDependencyCycle},
+		{"unknown", []Item{{Name: "a", DependsOn: []string{"b"}}}, ErrUnknownDependency},
+		{"duplicate", []Item{{Name: "a"}, {Name: "a"}}, ErrDuplicateItem},
+	}
+	for _, tt := range tests {
+		if _, err := order(tt.items); !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, but got %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestDependencyReadiness(t *testing.T) {
+	var backendReady, apiStarted int32
+
+	backend := newTestServer(nil)
+	api := newTestServer(nil)
+	d := NewDiagnosticsServer(0, testLogger)
+
+	p := New(testLogger,
+		Item{Name: "api", Server: &hookServer{testServer: api, start: func() {
+			if atomic.LoadInt32(&backendReady) == 0 {
+				t.Errorf("expected the backend to be ready before the api is started")
+			}
+			atomic.StoreInt32(&apiStarted, 1)
+		}}, DependsOn: []string{"backend"}},
+		Item{Name: "backend", Server: backend, Probe: func(context.Context) error {
+			if atomic.LoadInt32(&backendReady) == 0 {
+				return errors.New("warming up")
+			}
+			return nil
+		}},
+		Item{Name: "diagnostics", Server: &readyServer{testServer: newTestServer(nil), d: d}},
+	)
+	p.ProbeInterval = 10 * time.Millisecond
+
+	if _, err := p.start(context.Background()); err != nil {
+		t.Fatalf("expected start to pass, but got %v", err)
+	}
+	defer p.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt32(&apiStarted) != 0 {
+		t.Fatalf("expected the api to wait for the backend")
+	}
+	if code := readyz(d); code != http.StatusServiceUnavailable {
+		t.Errorf("expected /readyz to be unavailable, but got %d", code)
+	}
+
+	atomic.StoreInt32(&backendReady, 1)
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt32(&apiStarted) != 1 {
+		t.Fatalf("expected the api to be started")
+	}
+	if code := readyz(d); code != http.StatusOK {
+		t.Errorf("expected /readyz to be ok, but got %d", code)
+	}
+
+	// The pool follows the readiness of its items
+	atomic.StoreInt32(&backendReady, 0)
+	time.Sleep(50 * time.Millisecond)
+	if code := readyz(d); code != http.StatusServiceUnavailable {
+		t.Errorf("expected /readyz to be unavailable, but got %d", code)
+	}
+
+	p.Ready(true)
+	p.Ready(false)
+	if code := readyz(d); code != http.StatusServiceUnavailable {
+		t.Errorf("expected Ready(false) to mark the pool as not ready, but got %d", code)
+	}
+}
+
+func TestStartTimeout(t *testing.T) {
+	p := New(testLogger, Item{
+		Name:         "slow",
+		Server:       newTestServer(nil),
+		Probe:        func(context.Context) error { return errors.New("not ready") },
+		StartTimeout: 30 * time.Millisecond,
+	})
+	p.ProbeInterval = 10 * time.Millisecond
+
+	err := p.Run(context.Background())
+	var pe *PoolError
+	if !errors.As(err, &pe) || !errors.Is(pe.Errors[0], ErrStartTimeout) {
+		t.Errorf("expected a start timeout, but got %v", err)
+	}
+}
+
+// hookServer calls start before starting the embedded server
+type hookServer struct {
+	*testServer
+	start func()
+}
+
+func (s *hookServer) Start() error {
+	s.start()
+	return s.testServer.Start()
+}
+
+// readyServer forwards the readiness of the pool to a DiagnosticsServer
+type readyServer struct {
+	*testServer
+	d *DiagnosticsServer
+}
+
+func (s *readyServer) Ready(v bool) {
+	s.d.Ready(v)
+}
+
+func readyz(d *DiagnosticsServer) int {
+	w := httptest.NewRecorder()
+	d.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	return w.Code
+}
@@ -0,0 +1,143 @@
+package serverpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/arjanvaneersel/kit/logger"
+)
+
+// Worker turns a long running function into a Server. The function receives a context which is cancelled
+// when the worker is stopped and should return once the context is done.
+type Worker struct {
+	mu      sync.Mutex
+	name    string
+	fn      func(context.Context) error
+	cancel  context.CancelFunc
+	done    chan struct{}
+	stopped bool
+}
+
+// NewWorker returns a Worker which runs fn
+func NewWorker(name string, fn func(context.Context) error) *Worker {
+	return &Worker{name: name, fn: fn}
+}
+
+// WorkerItem returns a pool Item which runs fn
+func WorkerItem(name string, fn func(context.Context) error) Item {
+	return Item{Name: name, Server: NewWorker(name, fn)}
+}
+
+// Start runs the function of the worker and blocks until it returns. Errors caused by stopping the worker
+// aren't returned.
+func (w *Worker) Start() error {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	w.cancel, w.done = cancel, done
+	w.mu.Unlock()
+
+	defer close(done)
+	defer cancel()
+
+	err := w.fn(ctx)
+	if ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return nil
+	}
+	return err
+}
+
+// Stop cancels the context of the worker and waits until the function returned, or until ctx is done
+func (w *Worker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	w.stopped = true
+	cancel, done := w.cancel, w.done
+	w.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Address returns the name of the worker
+func (w *Worker) Address() string {
+	return "worker:" + w.name
+}
+
+// NewCron returns a Worker which runs fn according to the cron schedule spec, see ParseSchedule for the
+// supported syntax. A run is skipped when the previous run is still in progress. Errors of runs are logged
+// and don't stop the worker. Stopping the worker cancels the context of the run in progress and waits for it.
+func NewCron(name, spec string, fn func(context.Context) error, l logger.Logger) (*Worker, error) {
+	s, err := ParseSchedule(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	w := NewWorker(name, func(ctx context.Context) error {
+		var (
+			wg      sync.WaitGroup
+			running int32
+		)
+		defer wg.Wait()
+
+		for {
+			next := s.Next(time.Now())
+			if next.IsZero() {
+				l.Log(logger.WARN, "cron", name, "schedule has no next run", spec)
+				<-ctx.Done()
+				return nil
+			}
+
+			t := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return nil
+			case <-t.C:
+			}
+
+			if !atomic.CompareAndSwapInt32(&running, 0, 1) {
+				l.Log(logger.WARN, "cron", name, "skipped run", next, "previous run is still in progress")
+				continue
+			}
+
+			wg.Add(1)
+			go func(next time.Time) {
+				defer wg.Done()
+				defer atomic.StoreInt32(&running, 0)
+
+				start := time.Now()
+				if err := fn(ctx); err != nil {
+					l.Log(logger.ERROR, "cron", name, "run", next, "error", err)
+				} else {
+					l.Log(logger.DEBUG, "cron", name, "run", next, "duration", time.Since(start))
+				}
+			}(next)
+		}
+	})
+	return w, nil
+}
+
+// CronItem returns a pool Item which runs fn according to the cron schedule spec
+func CronItem(name, spec string, fn func(context.Context) error, l logger.Logger) (Item, error) {
+	w, err := NewCron(name, spec, fn, l)
+	if err != nil {
+		return Item{}, err
+	}
+	return Item{Name: name, Server: w}, nil
+}
@@ -0,0 +1,92 @@
+package serverpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorker(t *testing.T) {
+	var finished int32
+	w := NewWorker("consumer", func(ctx context.Context) error {
+		<-ctx.Done()
+		// Finish the in-flight message
+		time.Sleep(20 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+		return ctx.Err()
+	})
+
+	errChan := make(chan error, 1)
+	go func() { errChan <- w.Start() }()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := w.Stop(context.Background()); err != nil {
+		t.Fatalf("expected Stop to pass, but got %v", err)
+	}
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Errorf("expected Stop to wait for the in-flight work")
+	}
+	if err := <-errChan; err != nil {
+		t.Errorf("expected Start to return nil after Stop, but got %v", err)
+	}
+
+	errFailed := errors.New("failed")
+	w = NewWorker("failing", func(context.Context) error { return errFailed })
+	if err := w.Start(); err != errFailed {
+		t.Errorf("expected Start to return %v, but got %v", errFailed, err)
+	}
+
+	// Stop times out when the function ignores the context
+	block := make(chan struct{})
+	defer close(block)
+	w = NewWorker("stuck", func(context.Context) error {
+		<-block
+		return nil
+	})
+	go w.Start()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := w.Stop(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected Stop to time out, but got %v", err)
+	}
+}
+
+func TestCron(t *testing.T) {
+	var runs, inFlight int32
+	item, err := CronItem("cleanup", "@every 10ms", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Millisecond):
+		}
+		return nil
+	}, testLogger)
+	if err != nil {
+		t.Fatalf("expected CronItem to pass, but got %v", err)
+	}
+
+	p := New(testLogger, item)
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+	if err := p.Run(ctx); err != nil {
+		t.Fatalf("expected Run to pass, but got %v", err)
+	}
+
+	if n := atomic.LoadInt32(&runs); n < 2 {
+		t.Errorf("expected multiple runs, but got %d", n)
+	}
+	if n := atomic.LoadInt32(&inFlight); n != 0 {
+		t.Errorf("expected no runs in flight after stopping, but got %d", n)
+	}
+
+	if _, err := CronItem("invalid", "* * *", nil, testLogger); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("expected ErrInvalidSchedule, but got %v", err)
+	}
+}
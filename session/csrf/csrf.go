@@ -0,0 +1,344 @@
+// Package csrf protects cookie based session endpoints against cross-site request forgery with masked
+// synchronizer tokens. The secret behind the tokens is either kept in the session, which requires the
+// session middleware, or in a signed cookie (double-submit) for stateless setups.
+package csrf
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+
+	"github.com/arjanvaneersel/kit/session"
+)
+
+// tokenLength is the length of the secret and of the one-time pad which masks it
+const tokenLength = 32
+
+// sessionKey is the key under which the secret is stored in the session values
+const sessionKey = "_csrf"
+
+var (
+	ErrNoSession    = errors.New("csrf: no session in the request context")
+	ErrNoSecret     = errors.New("csrf: no secret found")
+	ErrNoToken      = errors.New("csrf: no token found in the request")
+	ErrInvalidToken = errors.New("csrf: invalid token")
+)
+
+// Defaults for the names used by a Protector
+var (
+	DefaultFieldName  = "csrf_token"
+	DefaultHeaderName = "X-CSRF-Token"
+	DefaultCookieName = "csrf"
+)
+
+type contextKey int
+
+const stateKey contextKey = iota
+
+// secretStore reads and creates the secret for a request
+type secretStore interface {
+	// get returns the secret of the request, or nil if there is none
+	get(w http.ResponseWriter, r *http.Request) ([]byte, error)
+
+	// create generates and stores a new secret
+	create(w http.ResponseWriter, r *http.Request) ([]byte, error)
+}
+
+// sessionStore keeps the secret in the session of the session middleware
+type sessionStore struct{}
+
+func (sessionStore) get(_ http.ResponseWriter, r *http.Request) ([]byte, error) {
+	h := session.FromContext(r.Context())
+	if h == nil {
+		return nil, ErrNoSession
+	}
+	b, _ := h.Get(sessionKey).([]byte)
+	return b, nil
+}
+
+func (sessionStore) create(_ http.ResponseWriter, r *http.Request) ([]byte, error) {
+	h := session.FromContext(r.Context())
+	if h == nil {
+		return nil, ErrNoSession
+	}
+	b, err := random()
+	if err != nil {
+		return nil, err
+	}
+	h.Set(sessionKey, b)
+	return b, nil
+}
+
+// cookieStore keeps the secret in a signed cookie
+type cookieStore struct {
+	m    *session.Manager
+	name string
+}
+
+func (s cookieStore) get(_ http.ResponseWriter, r *http.Request) ([]byte, error) {
+	c, err := r.Cookie(s.name)
+	if err != nil {
+		return nil, nil
+	}
+	var b []byte
+	if err := s.m.Decode(s.name, c.Value, &b); err != nil || len(b) != tokenLength {
+		return nil, nil
+	}
+	return b, nil
+}
+
+func (s cookieStore) create(w http.ResponseWriter, _ *http.Request) ([]byte, error) {
+	b, err := random()
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := s.m.Encode(s.name, b)
+	if err != nil {
+		return nil, err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.name,
+		Value:    encoded,
+		Path:     s.m.Options.Path,
+		Domain:   s.m.Options.Domain,
+		MaxAge:   s.m.Options.MaxAge,
+		Secure:   s.m.Options.Secure,
+		HttpOnly: true,
+		SameSite: s.m.Options.SameSite,
+	})
+	return b, nil
+}
+
+// Protector is http middleware which rejects requests with unsafe methods that don't carry a valid token
+type Protector struct {
+	store secretStore
+
+	// FieldName is the name of the form field which holds the token
+	FieldName string
+
+	// HeaderName is the name of the header which holds the token
+	HeaderName string
+
+	// ErrorHandler is called when a request is rejected, it defaults to a 403 Forbidden response
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+	mu     sync.RWMutex
+	exempt map[string]bool
+}
+
+// New returns a Protector which keeps the secret in the session. The handler has to be wrapped by the
+// session middleware.
+func New() *Protector {
+	return newProtector(sessionStore{})
+}
+
+// NewDoubleSubmit returns a Protector which keeps the secret in a cookie signed by m, so no session is needed
+func NewDoubleSubmit(m *session.Manager, cookieName string) *Protector {
+	if cookieName == "" {
+		cookieName = DefaultCookieName
+	}
+	return newProtector(cookieStore{m: m, name: cookieName})
+}
+
+func newProtector(s secretStore) *Protector {
+	return &Protector{
+		store:      s,
+		FieldName:  DefaultFieldName,
+		HeaderName: DefaultHeaderName,
+		exempt:     make(map[string]bool),
+	}
+}
+
+// Exempt disables protection for the provided paths, i.e. for webhooks authenticated by other means
+func (p *Protector) Exempt(paths ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, path := range paths {
+		p.exempt[path] = true
+	}
+}
+
+func (p *Protector) exempted(r *http.Request) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.exempt[r.URL.Path]
+}
+
+// state is stored in the request context so Token can access the secret
+type state struct {
+	p      *Protector
+	w      http.ResponseWriter
+	r      *http.Request
+	mu     sync.Mutex
+	secret []byte
+}
+
+func (s *state) getSecret(create bool) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.secret != nil {
+		return s.secret, nil
+	}
+
+	b, err := s.p.store.get(s.w, s.r)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil && create {
+		if b, err = s.p.store.create(s.w, s.r); err != nil {
+			return nil, err
+		}
+	}
+	s.secret = b
+	return b, nil
+}
+
+// Handler wraps the provided handler and validates tokens of requests with unsafe methods
+func (p *Protector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st := &state{p: p, w: w}
+		r = r.WithContext(context.WithValue(r.Context(), stateKey, st))
+		st.r = r
+
+		if _, ok := p.store.(cookieStore); ok {
+			// The cookie has to be set before the handler writes the response
+			if _, err := st.getSecret(true); err != nil {
+				p.fail(w, r, err)
+				return
+			}
+		}
+
+		if safeMethod(r.Method) || p.exempted(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		secret, err := st.getSecret(false)
+		if err != nil {
+			p.fail(w, r, err)
+			return
+		}
+		if secret == nil {
+			p.fail(w, r, ErrNoSecret)
+			return
+		}
+
+		t := r.Header.Get(p.HeaderName)
+		if t == "" {
+			t = r.PostFormValue(p.FieldName)
+		}
+		if t == "" {
+			p.fail(w, r, ErrNoToken)
+			return
+		}
+
+		if !valid(t, secret) {
+			p.fail(w, r, ErrInvalidToken)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Middleware is an alias of Handler for routers. With the session store it has to run after the session
+// middleware:
+//
+//	router.Use(sessions.Middleware, protector.Middleware)
+func (p *Protector) Middleware(next http.Handler) http.Handler {
+	return p.Handler(next)
+}
+
+func (p *Protector) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if p.ErrorHandler != nil {
+		p.ErrorHandler(w, r, err)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+}
+
+// Token returns a masked token for the request, creating the secret if needed.
+// Every call returns a different token, which protects the secret against BREACH style attacks.
+func Token(r *http.Request) (string, error) {
+	st, ok := r.Context().Value(stateKey).(*state)
+	if !ok {
+		return "", errors.New("csrf: request wasn't handled by a Protector")
+	}
+
+	secret, err := st.getSecret(true)
+	if err != nil {
+		return "", err
+	}
+	return mask(secret)
+}
+
+// TemplateField returns a hidden form field containing a token for the request, to be used in html/template as
+// {{ .CSRFField }}. It returns an empty string when no token can be created.
+func TemplateField(r *http.Request) template.HTML {
+	t, err := Token(r)
+	if err != nil {
+		return ""
+	}
+
+	name := DefaultFieldName
+	if st, ok := r.Context().Value(stateKey).(*state); ok {
+		name = st.p.FieldName
+	}
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`,
+		template.HTMLEscapeString(name), template.HTMLEscapeString(t)))
+}
+
+// safeMethod returns true for methods which, as defined in RFC 7231, must not change state
+func safeMethod(m string) bool {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func random() ([]byte, error) {
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// mask returns base64(pad || pad XOR secret) with a random one-time pad
+func mask(secret []byte) (string, error) {
+	pad, err := random()
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 2*tokenLength)
+	copy(b, pad)
+	for i := range secret {
+		b[tokenLength+i] = pad[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// valid unmasks the token and compares it with the secret in constant time
+func valid(token string, secret []byte) bool {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) != 2*tokenLength || len(secret) != tokenLength {
+		return false
+	}
+
+	unmasked := make([]byte, tokenLength)
+	for i := range unmasked {
+		unmasked[i] = b[i] ^ b[tokenLength+i]
+	}
+	return subtle.ConstantTimeCompare(unmasked, secret) == 1
+}
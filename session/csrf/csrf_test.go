@@ -0,0 +1,136 @@
+package csrf
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/arjanvaneersel/kit/session"
+	"github.com/gorilla/securecookie"
+)
+
+func newManager(t *testing.T) *session.Manager {
+	m, err := session.NewManager(securecookie.GenerateRandomKey(32), securecookie.GenerateRandomKey(32))
+	if err != nil {
+		t.Fatalf("expected NewManager to pass, but got %v", err)
+	}
+	return m
+}
+
+func handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			t, err := Token(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, t)
+		}
+	})
+}
+
+func do(h http.Handler, r *http.Request, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func testProtector(t *testing.T, h http.Handler) {
+	w := do(h, httptest.NewRequest("GET", "/", nil), nil)
+	token := w.Body.String()
+	cookies := w.Result().Cookies()
+	if w.Code != http.StatusOK || token == "" {
+		t.Fatalf("expected a token, but got status %d", w.Code)
+	}
+
+	tests := []struct {
+		name   string
+		req    func() *http.Request
+		status int
+	}{
+		{"no token", func() *http.Request { return httptest.NewRequest("POST", "/", nil) }, http.StatusForbidden},
+		{"invalid token", func() *http.Request {
+			r := httptest.NewRequest("POST", "/", nil)
+			r.Header.Set(DefaultHeaderName, "foo")
+			return r
+		}, http.StatusForbidden},
+		{"header", func() *http.Request {
+			r := httptest.NewRequest("POST", "/", nil)
+			r.Header.Set(DefaultHeaderName, token)
+			return r
+		}, http.StatusOK},
+		{"form", func() *http.Request {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(url.Values{DefaultFieldName: {token}}.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			return r
+		}, http.StatusOK},
+		{"exempt", func() *http.Request { return httptest.NewRequest("POST", "/webhook", nil) }, http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		if w := do(h, tc.req(), cookies); w.Code != tc.status {
+			t.Errorf("%s: expected status %d, but got %d", tc.name, tc.status, w.Code)
+		}
+	}
+
+	// A token without the matching cookie must fail
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set(DefaultHeaderName, token)
+	if w := do(h, r, nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected a token without cookie to fail, but got %d", w.Code)
+	}
+}
+
+func TestSessionProtector(t *testing.T) {
+	p := New()
+	p.Exempt("/webhook")
+	testProtector(t, session.NewMiddleware(newManager(t), "session").Handler(p.Handler(handler())))
+}
+
+func TestDoubleSubmitProtector(t *testing.T) {
+	p := NewDoubleSubmit(newManager(t), "")
+	p.Exempt("/webhook")
+	testProtector(t, p.Handler(handler()))
+}
+
+func TestDoubleSubmitCookiePath(t *testing.T) {
+	m := newManager(t)
+	m.Options.Path = "/app"
+	h := NewDoubleSubmit(m, "").Handler(handler())
+
+	w := do(h, httptest.NewRequest("GET", "/app/form", nil), nil)
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Path != "/app" {
+		t.Errorf("expected a cookie with the path of the session options, but got %v", cookies)
+	}
+}
+
+func TestMask(t *testing.T) {
+	secret, _ := random()
+	a, _ := mask(secret)
+	b, _ := mask(secret)
+	if a == b {
+		t.Errorf("expected masked tokens to differ")
+	}
+	if !valid(a, secret) || !valid(b, secret) {
+		t.Errorf("expected masked tokens to be valid")
+	}
+}
+
+func TestTemplateField(t *testing.T) {
+	var field string
+	h := NewDoubleSubmit(newManager(t), "").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		field = string(TemplateField(r))
+	}))
+	do(h, httptest.NewRequest("GET", "/", nil), nil)
+
+	if !strings.HasPrefix(field, `<input type="hidden" name="csrf_token" value="`) {
+		t.Errorf("unexpected field %q", field)
+	}
+}
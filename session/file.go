@@ -0,0 +1,169 @@
+package session
+
+import (
+	"context"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arjanvaneersel/kit/fo"
+)
+
+// fileExt is the extension of the files written by FileStore
+const fileExt = ".session"
+
+// FileStore is a Store which keeps every session in a gob encoded file in a directory.
+// The directory can be shared between replicas on a network file system.
+type FileStore struct {
+	mu  sync.RWMutex
+	Dir string
+}
+
+// NewFileStore returns a FileStore which stores sessions in dir. The directory will be created if it doesn't exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := fo.CreateDirectoryIfNotExist(dir); err != nil {
+		return nil, err
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.Dir, id+fileExt)
+}
+
+func (s *FileStore) read(path string) (*Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var sess Session
+	if err := gob.NewDecoder(f).Decode(&sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// Get implements the Store interface
+func (s *FileStore) Get(_ context.Context, id string) (*Session, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sess, err := s.read(s.path(id))
+	if err != nil {
+		return nil, err
+	}
+	if sess.Expired(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return sess, nil
+}
+
+// Save implements the Store interface. The session is written to a temporary file first, so readers never see
+// a partially written session.
+func (s *FileStore) Save(_ context.Context, sess *Session) error {
+	if !validID(sess.ID) {
+		return ErrInvalidID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := ioutil.TempFile(s.Dir, sess.ID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := gob.NewEncoder(f).Encode(sess); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(sess.ID))
+}
+
+// Delete implements the Store interface
+func (s *FileStore) Delete(_ context.Context, id string) error {
+	if !validID(id) {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return remove(s.path(id))
+}
+
+// DeleteUser implements the Store interface. All session files are read to find the sessions of the user.
+func (s *FileStore) DeleteUser(ctx context.Context, userID string) error {
+	return s.walk(ctx, func(path string, sess *Session) error {
+		if sess.UserID == userID {
+			return remove(path)
+		}
+		return nil
+	})
+}
+
+// Evict removes all expired sessions. It's meant to be called periodically, i.e. from a cron job.
+func (s *FileStore) Evict(ctx context.Context) error {
+	now := time.Now()
+	return s.walk(ctx, func(path string, sess *Session) error {
+		if sess.Expired(now) {
+			return remove(path)
+		}
+		return nil
+	})
+}
+
+// walk calls fn for every readable session file while holding the write lock
+func (s *FileStore) walk(ctx context.Context, fn func(string, *Session) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := ioutil.ReadDir(s.Dir)
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), fileExt) {
+			continue
+		}
+
+		path := filepath.Join(s.Dir, fi.Name())
+		sess, err := s.read(path)
+		if err != nil {
+			// Skip files which can't be decoded or which were removed in the meantime
+			continue
+		}
+		if err := fn(path, sess); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-memory Store. Expired sessions are evicted periodically.
+// Sessions are lost on restart and aren't shared between replicas.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+	users    map[string]map[string]bool
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// NewMemoryStore returns a MemoryStore which evicts expired sessions every interval.
+// Eviction is disabled when interval is zero, expired sessions are never returned by Get either way.
+func NewMemoryStore(interval time.Duration) *MemoryStore {
+	s := MemoryStore{
+		sessions: make(map[string]*Session),
+		users:    make(map[string]map[string]bool),
+		stop:     make(chan struct{}),
+	}
+
+	if interval > 0 {
+		go s.evict(interval)
+	}
+	return &s
+}
+
+func (s *MemoryStore) evict(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-t.C:
+			s.mu.Lock()
+			for id, sess := range s.sessions {
+				if sess.Expired(now) {
+					s.delete(id)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Close stops the eviction of expired sessions
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+// Len returns the number of stored sessions, including expired sessions which haven't been evicted yet
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.sessions)
+}
+
+// Get implements the Store interface
+func (s *MemoryStore) Get(_ context.Context, id string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sess, ok := s.sessions[id]
+	if !ok || sess.Expired(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return copySession(sess), nil
+}
+
+// Save implements the Store interface
+func (s *MemoryStore) Save(_ context.Context, sess *Session) error {
+	if !validID(sess.ID) {
+		return ErrInvalidID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The user of a session may change, i.e. when an anonymous session logs in
+	s.delete(sess.ID)
+
+	s.sessions[sess.ID] = copySession(sess)
+	if sess.UserID != "" {
+		if s.users[sess.UserID] == nil {
+			s.users[sess.UserID] = make(map[string]bool)
+		}
+		s.users[sess.UserID][sess.ID] = true
+	}
+	return nil
+}
+
+// Delete implements the Store interface
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delete(id)
+	return nil
+}
+
+// DeleteUser implements the Store interface
+func (s *MemoryStore) DeleteUser(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.users[userID] {
+		s.delete(id)
+	}
+	return nil
+}
+
+// delete removes a session, the caller must hold the lock
+func (s *MemoryStore) delete(id string) {
+	sess, ok := s.sessions[id]
+	if !ok {
+		return
+	}
+
+	delete(s.sessions, id)
+	if ids := s.users[sess.UserID]; ids != nil {
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(s.users, sess.UserID)
+		}
+	}
+}
@@ -0,0 +1,468 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/arjanvaneersel/kit/logger"
+)
+
+// flashKey is the key under which flash messages are stored in the session values
+const flashKey = "_flash"
+
+type contextKey int
+
+const handleKey contextKey = iota
+
+// backend loads and persists sessions for the middleware
+type backend interface {
+	// load returns the session of the request or ErrNotFound if there is no valid session
+	load(r *http.Request) (*Session, error)
+
+	// save persists the session and sends the cookie to the client
+	save(ctx context.Context, w http.ResponseWriter, s *Session) error
+
+	// remove deletes the session and the cookie
+	remove(ctx context.Context, w http.ResponseWriter, s *Session) error
+
+	// forget deletes a session which has been replaced by a session with a new id
+	forget(ctx context.Context, id string) error
+}
+
+// cookieBackend keeps the complete session in the cookie
+type cookieBackend struct {
+	m    *Manager
+	name string
+}
+
+func (b cookieBackend) load(r *http.Request) (*Session, error) {
+	c, err := r.Cookie(b.name)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var s Session
+	if err := b.m.Decode(b.name, c.Value, &s); err != nil {
+		return nil, ErrNotFound
+	}
+	return &s, nil
+}
+
+func (b cookieBackend) save(_ context.Context, w http.ResponseWriter, s *Session) error {
+	encoded, err := b.m.Encode(b.name, s)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, b.m.cookie(b.name, encoded, b.m.Options.MaxAge))
+	return nil
+}
+
+func (b cookieBackend) remove(_ context.Context, w http.ResponseWriter, _ *Session) error {
+	b.m.Destroy(w, b.name)
+	return nil
+}
+
+func (b cookieBackend) forget(context.Context, string) error {
+	return nil
+}
+
+// storeBackend keeps the session in a Store and the session id in the cookie
+type storeBackend struct {
+	m *StoreManager
+}
+
+func (b storeBackend) load(r *http.Request) (*Session, error) {
+	id, err := b.m.ID(r)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return b.m.Store.Get(r.Context(), id)
+}
+
+func (b storeBackend) save(ctx context.Context, w http.ResponseWriter, s *Session) error {
+	s.ExpiresAt = b.m.expires(time.Now())
+	if err := b.m.Store.Save(ctx, s); err != nil {
+		return err
+	}
+	return b.m.setCookie(w, s.ID)
+}
+
+func (b storeBackend) remove(ctx context.Context, w http.ResponseWriter, s *Session) error {
+	return b.m.Destroy(ctx, w, s)
+}
+
+func (b storeBackend) forget(ctx context.Context, id string) error {
+	return b.m.Store.Delete(ctx, id)
+}
+
+// Middleware is http middleware which makes the session of a request available through FromContext.
+// The session is loaded on first use and only written back when it has been modified.
+type Middleware struct {
+	backend backend
+
+	// IdleTimeout expires sessions which haven't been used for the given duration, zero disables the timeout
+	IdleTimeout time.Duration
+
+	// AbsoluteTimeout expires sessions which were created longer than the given duration ago, zero disables
+	// the timeout
+	AbsoluteTimeout time.Duration
+
+	// ErrorHandler is called when a session can't be loaded or saved. When the error occurs before the response
+	// has been written the default handler responds with an internal server error.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// NewMiddleware returns a Middleware which stores sessions in the cookie with the provided name
+func NewMiddleware(m *Manager, name string) *Middleware {
+	return &Middleware{backend: cookieBackend{m: m, name: name}}
+}
+
+// NewStoreMiddleware returns a Middleware which stores sessions server side
+func NewStoreMiddleware(m *StoreManager) *Middleware {
+	return &Middleware{backend: storeBackend{m: m}}
+}
+
+// Handler wraps the provided handler and provides it with the session
+func (mw *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := &Handle{mw: mw, r: r}
+		sw := &writer{ResponseWriter: w, h: h}
+		h.w = w
+
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), handleKey, h)))
+
+		if !sw.wroteHeader {
+			if err := h.commit(); err != nil {
+				mw.handleError(w, r, err, true)
+			}
+		}
+	})
+}
+
+// Middleware is an alias of Handler for routers: router.Use(mw.Middleware). Routes which don't touch the
+// session don't pay for it, since the session is only loaded when it's first used.
+func (mw *Middleware) Middleware(next http.Handler) http.Handler {
+	return mw.Handler(next)
+}
+
+func (mw *Middleware) handleError(w http.ResponseWriter, r *http.Request, err error, canRespond bool) {
+	if mw.ErrorHandler != nil {
+		mw.ErrorHandler(w, r, err)
+		return
+	}
+	if canRespond {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// expired checks the idle and absolute timeouts of a session
+func (mw *Middleware) expired(s *Session, now time.Time) bool {
+	if mw.AbsoluteTimeout > 0 && now.Sub(s.CreatedAt) > mw.AbsoluteTimeout {
+		return true
+	}
+	if mw.IdleTimeout > 0 && now.Sub(s.AccessedAt) > mw.IdleTimeout {
+		return true
+	}
+	return s.Expired(now)
+}
+
+// FromContext returns the session handle stored in the context by the Middleware, or nil if there is none
+func FromContext(ctx context.Context) *Handle {
+	h, _ := ctx.Value(handleKey).(*Handle)
+	return h
+}
+
+// LogFields is a logger.Extractor which adds the id of the session as "session_id" field. Sessions aren't loaded
+// for logging, so the field is only added once the handler used the session.
+//
+//	logger.RegisterExtractor(session.LogFields)
+func LogFields(ctx context.Context) []logger.Field {
+	h := FromContext(ctx)
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.loaded || h.s == nil || h.s.ID == "" {
+		return nil
+	}
+	return []logger.Field{logger.String("session_id", h.s.ID)}
+}
+
+// Handle gives a handler access to the session of the current request. It's safe for concurrent use.
+type Handle struct {
+	mu sync.Mutex
+	mw *Middleware
+	r  *http.Request
+	w  http.ResponseWriter
+
+	s          *Session
+	loaded     bool
+	err        error
+	dirty      bool
+	destroyed  bool
+	regenerate bool
+	committed  bool
+	expired    *Session
+}
+
+// load loads the session on first use, the caller must hold the lock
+func (h *Handle) load() *Session {
+	if h.loaded {
+		return h.s
+	}
+	h.loaded = true
+
+	now := time.Now()
+	s, err := h.mw.backend.load(h.r)
+	switch {
+	case err == nil && h.mw.expired(s, now):
+		h.expired = s
+	case err == nil:
+		h.s = s
+		// Only touch sessions with an idle timeout, and not on every request, to avoid a write per request
+		if h.mw.IdleTimeout > 0 && now.Sub(s.AccessedAt) > h.mw.IdleTimeout/10 {
+			s.AccessedAt = now
+			h.dirty = true
+		}
+	case !errors.Is(err, ErrNotFound):
+		h.err = err
+	}
+
+	if h.s == nil {
+		h.s = &Session{Values: make(Values), CreatedAt: now, AccessedAt: now}
+	}
+	if h.s.Values == nil {
+		h.s.Values = make(Values)
+	}
+	return h.s
+}
+
+// Err returns the error which occurred while loading the session. The handle continues with an empty session
+// in that case.
+func (h *Handle) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.load()
+	return h.err
+}
+
+// ID returns the id of the session, which is empty for a new session until it has been saved
+func (h *Handle) ID() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.load().ID
+}
+
+// UserID returns the id of the user the session belongs to
+func (h *Handle) UserID() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.load().UserID
+}
+
+// Get returns the value stored under k
+func (h *Handle) Get(k string) interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.load().Values[k]
+}
+
+// Values returns a copy of the session values
+func (h *Handle) Values() Values {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return copySession(h.load()).Values
+}
+
+// Set stores v under k and marks the session as modified
+func (h *Handle) Set(k string, v interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.load().Values[k] = v
+	h.dirty = true
+}
+
+// Delete removes the value stored under k
+func (h *Handle) Delete(k string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.load()
+	if _, ok := s.Values[k]; ok {
+		delete(s.Values, k)
+		h.dirty = true
+	}
+}
+
+// AddFlash adds a flash message, which is removed from the session once it has been read with Flashes
+func (h *Handle) AddFlash(v interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.load()
+	flashes, _ := s.Values[flashKey].([]interface{})
+	s.Values[flashKey] = append(flashes, v)
+	h.dirty = true
+}
+
+// Flashes returns and removes all flash messages
+func (h *Handle) Flashes() []interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.load()
+	flashes, ok := s.Values[flashKey].([]interface{})
+	if ok {
+		delete(s.Values, flashKey)
+		h.dirty = true
+	}
+	return flashes
+}
+
+// Regenerate gives the session a new id and assigns it to userID. It should be called whenever the privileges
+// of a session change, i.e. at login, to prevent session fixation. The absolute timeout starts again.
+func (h *Handle) Regenerate(userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.load()
+	s.UserID = userID
+	s.CreatedAt = time.Now()
+	h.regenerate = true
+	h.dirty = true
+}
+
+// Destroy removes the session, i.e. at logout
+func (h *Handle) Destroy() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.load()
+	h.destroyed = true
+}
+
+// commit writes the session when it has been modified. It runs once, before the headers are sent.
+func (h *Handle) commit() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.committed {
+		return nil
+	}
+	h.committed = true
+
+	ctx := h.r.Context()
+	if h.expired != nil {
+		if err := h.mw.backend.remove(ctx, h.w, h.expired); err != nil {
+			return err
+		}
+	}
+
+	if !h.loaded {
+		return nil
+	}
+
+	if h.destroyed {
+		if h.s.ID == "" {
+			return nil
+		}
+		return h.mw.backend.remove(ctx, h.w, h.s)
+	}
+
+	if !h.dirty {
+		return nil
+	}
+
+	old := h.s.ID
+	if old == "" || h.regenerate {
+		id, err := NewID()
+		if err != nil {
+			return err
+		}
+		h.s.ID = id
+	}
+
+	if err := h.mw.backend.save(ctx, h.w, h.s); err != nil {
+		return err
+	}
+	if old != "" && old != h.s.ID {
+		return h.mw.backend.forget(ctx, old)
+	}
+	return nil
+}
+
+// writer commits the session before the headers are written
+type writer struct {
+	http.ResponseWriter
+	h           *Handle
+	wroteHeader bool
+}
+
+func (w *writer) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if err := w.h.commit(); err != nil {
+			w.h.mw.handleError(w.ResponseWriter, w.h.r, err, false)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher
+func (w *writer) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (w *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	return h.Hijack()
+}
+
+// Push implements http.Pusher when the underlying writer does
+func (w *writer) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// ReadFrom implements io.ReaderFrom, so the underlying writer can use sendfile
+func (w *writer) ReadFrom(r io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(w.ResponseWriter, r)
+}
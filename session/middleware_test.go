@@ -0,0 +1,201 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjanvaneersel/kit/logger"
+	"github.com/arjanvaneersel/kit/metrics"
+	"github.com/gorilla/securecookie"
+)
+
+// client keeps the cookies of the previous responses, like a browser
+type client struct {
+	cookies map[string]*http.Cookie
+	h       http.Handler
+}
+
+func (c *client) do(path string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", path, nil)
+	for _, ck := range c.cookies {
+		r.AddCookie(ck)
+	}
+
+	w := httptest.NewRecorder()
+	c.h.ServeHTTP(w, r)
+	for _, ck := range w.Result().Cookies() {
+		if ck.MaxAge < 0 {
+			delete(c.cookies, ck.Name)
+			continue
+		}
+		c.cookies[ck.Name] = ck
+	}
+	return w
+}
+
+func testHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/set", func(w http.ResponseWriter, r *http.Request) {
+		h := FromContext(r.Context())
+		h.Set("foo", r.URL.Query().Get("v"))
+		h.AddFlash("saved")
+		fmt.Fprint(w, "ok")
+	})
+	mux.HandleFunc("/get", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, FromContext(r.Context()).Get("foo"))
+	})
+	mux.HandleFunc("/flash", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, FromContext(r.Context()).Flashes()...)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Regenerate("alice")
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Destroy()
+	})
+	return mux
+}
+
+func newTestManager(t *testing.T) *Manager {
+	m, err := NewManager(securecookie.GenerateRandomKey(32), securecookie.GenerateRandomKey(32))
+	if err != nil {
+		t.Fatalf("expected NewManager to pass, but got %v", err)
+	}
+	return m
+}
+
+func testMiddleware(t *testing.T, mw *Middleware) {
+	c := &client{cookies: make(map[string]*http.Cookie), h: mw.Handler(testHandler())}
+
+	if w := c.do("/get"); len(w.Result().Cookies()) != 0 {
+		t.Errorf("expected an unmodified session not to set a cookie")
+	}
+
+	c.do("/set?v=bar")
+	if got := c.do("/get").Body.String(); got != "bar" {
+		t.Errorf("expected bar, but got %q", got)
+	}
+	if w := c.do("/get"); len(w.Result().Cookies()) != 0 {
+		t.Errorf("expected an unmodified session not to set a cookie")
+	}
+
+	if got := c.do("/flash").Body.String(); got != "saved" {
+		t.Errorf("expected flash saved, but got %q", got)
+	}
+	if got := c.do("/flash").Body.String(); got != "" {
+		t.Errorf("expected flashes to be read once, but got %q", got)
+	}
+
+	c.do("/login")
+	if got := c.do("/get").Body.String(); got != "bar" {
+		t.Errorf("expected the values to survive regeneration, but got %q", got)
+	}
+
+	c.do("/logout")
+	if got := c.do("/get").Body.String(); got != "<nil>" {
+		t.Errorf("expected an empty session after logout, but got %q", got)
+	}
+}
+
+func TestCookieMiddleware(t *testing.T) {
+	testMiddleware(t, NewMiddleware(newTestManager(t), "session"))
+}
+
+func TestStoreMiddleware(t *testing.T) {
+	store := NewMemoryStore(0)
+	testMiddleware(t, NewStoreMiddleware(NewStoreManager(newTestManager(t), store, "sid")))
+
+	if l := store.Len(); l != 0 {
+		t.Errorf("expected all sessions to be removed, but found %d", l)
+	}
+}
+
+func TestMiddlewareTimeouts(t *testing.T) {
+	m := newTestManager(t)
+	mw := NewMiddleware(m, "session")
+	mw.AbsoluteTimeout = time.Hour
+	c := &client{cookies: make(map[string]*http.Cookie), h: mw.Handler(testHandler())}
+
+	// Forge a session which was created two hours ago
+	encoded, err := m.Encode("session", &Session{ID: "old", Values: Values{"foo": "bar"}, CreatedAt: time.Now().Add(-2 * time.Hour)})
+	if err != nil {
+		t.Fatalf("expected Encode to pass, but got %v", err)
+	}
+	c.cookies["session"] = &http.Cookie{Name: "session", Value: encoded}
+
+	if got := c.do("/get").Body.String(); got != "<nil>" {
+		t.Errorf("expected an expired session to be empty, but got %q", got)
+	}
+	if _, ok := c.cookies["session"]; ok {
+		t.Errorf("expected the expired session cookie to be removed")
+	}
+}
+
+func TestFromContextWithoutMiddleware(t *testing.T) {
+	if h := FromContext(context.Background()); h != nil {
+		t.Errorf("expected nil, but got %v", h)
+	}
+}
+
+func TestLogFields(t *testing.T) {
+	m := newTestManager(t)
+	encoded, err := m.Encode("session", &Session{ID: "abc", Values: Values{}, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("expected Encode to pass, but got %v", err)
+	}
+
+	var before, after []logger.Field
+	h := NewMiddleware(m, "session").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		before = LogFields(r.Context())
+		FromContext(r.Context()).Get("foo")
+		after = LogFields(r.Context())
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: encoded})
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if before != nil {
+		t.Errorf("expected no fields before the session is loaded, but got %v", before)
+	}
+	if len(after) != 1 || after[0].Key != "session_id" || after[0].Str != "abc" {
+		t.Errorf("expected the session id field, but got %v", after)
+	}
+}
+
+func TestStackedWriters(t *testing.T) {
+	var ok []bool
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flusher := w.(http.Flusher)
+		_, hijacker := w.(http.Hijacker)
+		_, pusher := w.(http.Pusher)
+		_, readerFrom := w.(io.ReaderFrom)
+		ok = []bool{flusher, hijacker, pusher, readerFrom}
+
+		FromContext(r.Context()).Set("foo", "bar")
+		io.Copy(w, strings.NewReader("ok"))
+	})
+	l := logger.NewStructured(io.Discard, logger.INFO, logger.JSONEncoder{})
+	m := metrics.NewHTTPMetrics(metrics.NewRegistry())
+	s := httptest.NewServer(logger.AccessLog(l)(m.Middleware(NewMiddleware(newTestManager(t), "session").Handler(h))))
+	defer s.Close()
+
+	res, err := http.Get(s.URL)
+	if err != nil {
+		t.Fatalf("expected the request to pass, but got %v", err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	if fmt.Sprint(ok) != "[true true true true]" {
+		t.Errorf("expected the stacked writers to keep the optional interfaces, but got %v", ok)
+	}
+	if string(body) != "ok" || len(res.Cookies()) != 1 {
+		t.Errorf("expected the body and the session cookie, but got %q and %v", body, res.Cookies())
+	}
+}
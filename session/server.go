@@ -0,0 +1,143 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// StoreManager manages server side sessions. The cookie only contains the signed and encrypted session id,
+// the session data is kept in a Store, which allows sessions to be invalidated on the server.
+type StoreManager struct {
+	*Manager
+	Store Store
+
+	// Name is the name of the session cookie
+	Name string
+
+	// TTL is the lifetime of a session, it defaults to the max age of the cookie
+	TTL time.Duration
+}
+
+// NewStoreManager returns a StoreManager which uses m for the session cookie named name and s for storage
+func NewStoreManager(m *Manager, s Store, name string) *StoreManager {
+	return &StoreManager{
+		Manager: m,
+		Store:   s,
+		Name:    name,
+		TTL:     time.Duration(m.Options.MaxAge) * time.Second,
+	}
+}
+
+func (m *StoreManager) expires(t time.Time) time.Time {
+	if m.TTL <= 0 {
+		return time.Time{}
+	}
+	return t.Add(m.TTL)
+}
+
+func (m *StoreManager) setCookie(w http.ResponseWriter, id string) error {
+	encoded, err := m.Encode(m.Name, id)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, m.cookie(m.Name, encoded, m.Options.MaxAge))
+	return nil
+}
+
+// New creates and stores a new session for the provided user, which may be empty for anonymous sessions,
+// and sends the session cookie to the client
+func (m *StoreManager) New(ctx context.Context, w http.ResponseWriter, userID string, v Values) (*Session, error) {
+	id, err := NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		v = make(Values)
+	}
+
+	now := time.Now()
+	s := &Session{
+		ID:         id,
+		UserID:     userID,
+		Values:     v,
+		CreatedAt:  now,
+		AccessedAt: now,
+		ExpiresAt:  m.expires(now),
+	}
+	if err := m.Store.Save(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, m.setCookie(w, id)
+}
+
+// ID returns the verified session id from the request's session cookie
+func (m *StoreManager) ID(r *http.Request) (string, error) {
+	c, err := r.Cookie(m.Name)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	if err := m.Decode(m.Name, c.Value, &id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Get returns the session belonging to the request. Returns ErrNotFound if the session doesn't exist anymore.
+func (m *StoreManager) Get(r *http.Request) (*Session, error) {
+	id, err := m.ID(r)
+	if err != nil {
+		return nil, err
+	}
+	return m.Store.Get(r.Context(), id)
+}
+
+// Save stores the modified session
+func (m *StoreManager) Save(ctx context.Context, s *Session) error {
+	return m.Store.Save(ctx, s)
+}
+
+// Renew extends the lifetime of the session by TTL and refreshes the cookie
+func (m *StoreManager) Renew(ctx context.Context, w http.ResponseWriter, s *Session) error {
+	s.ExpiresAt = m.expires(time.Now())
+	if err := m.Store.Save(ctx, s); err != nil {
+		return err
+	}
+	return m.setCookie(w, s.ID)
+}
+
+// Regenerate gives the session a new id and removes the old one. It should be called whenever the privileges of
+// a session change, i.e. at login, to prevent session fixation. The user id of the session is set to userID.
+func (m *StoreManager) Regenerate(ctx context.Context, w http.ResponseWriter, s *Session, userID string) error {
+	id, err := NewID()
+	if err != nil {
+		return err
+	}
+
+	old := s.ID
+	s.ID = id
+	s.UserID = userID
+	s.ExpiresAt = m.expires(time.Now())
+	if err := m.Store.Save(ctx, s); err != nil {
+		s.ID = old
+		return err
+	}
+	if err := m.Store.Delete(ctx, old); err != nil {
+		return err
+	}
+	return m.setCookie(w, id)
+}
+
+// Destroy removes the session from the store and the cookie from the client
+func (m *StoreManager) Destroy(ctx context.Context, w http.ResponseWriter, s *Session) error {
+	m.Manager.Destroy(w, m.Name)
+	return m.Store.Delete(ctx, s.ID)
+}
+
+// DestroyUser removes all sessions of a user, which logs the user out everywhere
+func (m *StoreManager) DestroyUser(ctx context.Context, userID string) error {
+	return m.Store.DeleteUser(ctx, userID)
+}
@@ -1,52 +1,283 @@
 package session
 
 import (
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/arjanvaneersel/kit/cfg"
 	"github.com/gorilla/securecookie"
 )
 
-type SessionValues map[string]interface{}
+var (
+	// ErrNoKeys is returned when a Manager is created without hash keys
+	ErrNoKeys = errors.New("at least one hash key is required")
 
-var cookieHandler = securecookie.New(
-	securecookie.GenerateRandomKey(64),
-	securecookie.GenerateRandomKey(32),
+	// ErrInvalidHashKey is returned for hash keys which are too short to authenticate cookies safely
+	ErrInvalidHashKey = errors.New("hash key must be at least 32 bytes")
+
+	// ErrInvalidBlockKey is returned for block keys which aren't a valid AES key size
+	ErrInvalidBlockKey = errors.New("block key must be 16, 24 or 32 bytes")
+
+	// ErrKeyMismatch is returned when block keys are configured, but not one for every hash key
+	ErrKeyMismatch = errors.New("the number of hash and block keys must be equal")
+
+	// ErrInvalidSameSite is returned by ParseSameSite for values other than lax, strict, none or an empty string
+	ErrInvalidSameSite = errors.New("invalid SameSite value")
 )
 
-func CreateSession(w http.ResponseWriter, name string, values *SessionValues) {
-	if encoded, err := cookieHandler.Encode(name, *values); err == nil {
-		c := &http.Cookie{
-			Name:  name,
-			Value: encoded,
-			Path:  "/",
+func init() {
+	// Values are gob encoded, so register the types which commonly end up in a session
+	gob.Register(time.Time{})
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+}
+
+// Values contains the data of a session. Custom types stored in Values have to be registered with gob.Register.
+type Values map[string]interface{}
+
+// String returns the value stored under k as a string
+func (v Values) String(k string) (string, bool) {
+	s, ok := v[k].(string)
+	return s, ok
+}
+
+// Int returns the value stored under k as an int
+func (v Values) Int(k string) (int, bool) {
+	i, ok := v[k].(int)
+	return i, ok
+}
+
+// Bool returns the value stored under k as a bool
+func (v Values) Bool(k string) (bool, bool) {
+	b, ok := v[k].(bool)
+	return b, ok
+}
+
+// Time returns the value stored under k as a time.Time
+func (v Values) Time(k string) (time.Time, bool) {
+	t, ok := v[k].(time.Time)
+	return t, ok
+}
+
+// CookieOptions contains the attributes which are set on session cookies
+type CookieOptions struct {
+	Path     string
+	Domain   string
+	MaxAge   int
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// DefaultCookieOptions returns secure default cookie attributes with a max age of 30 days
+func DefaultCookieOptions() CookieOptions {
+	return CookieOptions{
+		Path:     "/",
+		MaxAge:   86400 * 30,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// Manager creates, reads and destroys cookie based sessions
+type Manager struct {
+	Options CookieOptions
+	codecs  []securecookie.Codec
+}
+
+// NewManager returns a Manager which signs cookies with the hash key and encrypts them with the block key.
+// Keys are provided in pairs of hash and block keys, i.e. NewManager(hash, block, oldHash, oldBlock).
+// The first pair is used to create cookies, all pairs are tried when reading cookies, which allows rotation of keys.
+// A block key may be nil, in which case the cookie is signed but not encrypted.
+func NewManager(keyPairs ...[]byte) (*Manager, error) {
+	if len(keyPairs) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	for i, k := range keyPairs {
+		if i%2 == 0 && len(k) < 32 {
+			return nil, ErrInvalidHashKey
+		}
+		if i%2 == 1 && k != nil && len(k) != 16 && len(k) != 24 && len(k) != 32 {
+			return nil, ErrInvalidBlockKey
+		}
+	}
+
+	m := Manager{
+		Options: DefaultCookieOptions(),
+		codecs:  securecookie.CodecsFromPairs(keyPairs...),
+	}
+	m.SetMaxAge(m.Options.MaxAge)
+	return &m, nil
+}
+
+// NewManagerFromConfig returns a Manager configured with the following keys:
+//
+//	SESSION_HASH_KEYS   comma separated list of base64 encoded hash keys, newest first (required)
+//	SESSION_BLOCK_KEYS  comma separated list of base64 encoded block keys, matching the hash keys
+//	SESSION_PATH, SESSION_DOMAIN, SESSION_MAX_AGE (duration), SESSION_SECURE, SESSION_HTTP_ONLY
+//	SESSION_SAME_SITE   one of lax, strict, none
+func NewManagerFromConfig(c *cfg.Config) (*Manager, error) {
+	hashKeys, err := decodeKeys(c, "SESSION_HASH_KEYS")
+	if err != nil {
+		return nil, err
+	}
+	if len(hashKeys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	blockKeys, err := decodeKeys(c, "SESSION_BLOCK_KEYS")
+	if err != nil {
+		return nil, err
+	}
+	if len(blockKeys) > 0 && len(blockKeys) != len(hashKeys) {
+		return nil, ErrKeyMismatch
+	}
+
+	var pairs [][]byte
+	for i, k := range hashKeys {
+		var b []byte
+		if len(blockKeys) > 0 {
+			b = blockKeys[i]
 		}
-		http.SetCookie(w, c)
+		pairs = append(pairs, k, b)
 	}
+
+	m, err := NewManager(pairs...)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, err := c.GetString("SESSION_PATH"); err == nil {
+		m.Options.Path = v
+	}
+	if v, err := c.GetString("SESSION_DOMAIN"); err == nil {
+		m.Options.Domain = v
+	}
+	if v, err := c.GetBool("SESSION_SECURE"); err == nil {
+		m.Options.Secure = v
+	}
+	if v, err := c.GetBool("SESSION_HTTP_ONLY"); err == nil {
+		m.Options.HttpOnly = v
+	}
+	if v, err := c.GetString("SESSION_SAME_SITE"); err == nil {
+		ss, err := ParseSameSite(v)
+		if err != nil {
+			return nil, err
+		}
+		m.Options.SameSite = ss
+	}
+	if v, err := c.GetDuration("SESSION_MAX_AGE"); err == nil {
+		m.SetMaxAge(int(v / time.Second))
+	}
+
+	return m, nil
+}
+
+func decodeKeys(c *cfg.Config, k string) ([][]byte, error) {
+	s, err := c.GetSlice(k)
+	if err != nil {
+		if _, ok := err.(cfg.ErrKeyNotFound); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var keys [][]byte
+	for _, v := range s {
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", k, err)
+		}
+		keys = append(keys, b)
+	}
+	return keys, nil
+}
+
+// ParseSameSite converts lax, strict, none or an empty string to a http.SameSite value
+func ParseSameSite(s string) (http.SameSite, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return http.SameSiteDefaultMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return http.SameSiteDefaultMode, ErrInvalidSameSite
 }
 
-func DestroySession(w http.ResponseWriter, name string) {
+// SetMaxAge sets the max age of the cookies and of the timestamps in the encoded values
+func (m *Manager) SetMaxAge(age int) {
+	m.Options.MaxAge = age
+	for _, c := range m.codecs {
+		if sc, ok := c.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+func (m *Manager) cookie(name, value string, maxAge int) *http.Cookie {
 	c := &http.Cookie{
-		Name:   name,
-		Value:  "",
-		Path:   "/",
-		MaxAge: -1,
+		Name:     name,
+		Value:    value,
+		Path:     m.Options.Path,
+		Domain:   m.Options.Domain,
+		MaxAge:   maxAge,
+		Secure:   m.Options.Secure,
+		HttpOnly: m.Options.HttpOnly,
+		SameSite: m.Options.SameSite,
+	}
+	if maxAge > 0 {
+		c.Expires = time.Now().Add(time.Duration(maxAge) * time.Second)
 	}
-	http.SetCookie(w, c)
+	return c
 }
 
-func GetValues(name string, r *http.Request) (SessionValues, error) {
+// Encode signs and encrypts the provided value with the newest key pair
+func (m *Manager) Encode(name string, v interface{}) (string, error) {
+	return securecookie.EncodeMulti(name, v, m.codecs...)
+}
+
+// Decode verifies and decrypts the provided value with any of the configured key pairs
+func (m *Manager) Decode(name, value string, dst interface{}) error {
+	return securecookie.DecodeMulti(name, value, dst, m.codecs...)
+}
+
+// Save stores the values in a cookie with the provided name
+func (m *Manager) Save(w http.ResponseWriter, name string, v Values) error {
+	encoded, err := m.Encode(name, v)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, m.cookie(name, encoded, m.Options.MaxAge))
+	return nil
+}
+
+// Load reads the values from the cookie with the provided name.
+// Returns http.ErrNoCookie if the request doesn't contain the cookie.
+func (m *Manager) Load(r *http.Request, name string) (Values, error) {
 	c, err := r.Cookie(name)
 	if err != nil {
 		return nil, err
 	}
 
-	sValues := make(SessionValues)
-	if err = cookieHandler.Decode(name, c.Value, &sValues); err != nil {
+	v := make(Values)
+	if err = m.Decode(name, c.Value, &v); err != nil {
 		return nil, err
 	}
-	return sValues, nil
+	return v, nil
 }
 
-func Get
-
-//Todo: Tests, comments and documentation
+// Destroy removes the cookie with the provided name from the client
+func (m *Manager) Destroy(w http.ResponseWriter, name string) {
+	http.SetCookie(w, m.cookie(name, "", -1))
+}
@@ -0,0 +1,110 @@
+package session
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/arjanvaneersel/kit/cfg"
+	"github.com/gorilla/securecookie"
+)
+
+func roundTrip(t *testing.T, save, load *Manager, v Values) (Values, error) {
+	w := httptest.NewRecorder()
+	if err := save.Save(w, "session", v); err != nil {
+		t.Fatalf("expected Save to pass, but got %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	for _, c := range w.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	return load.Load(r, "session")
+}
+
+func TestManager(t *testing.T) {
+	m, err := NewManager(securecookie.GenerateRandomKey(64), securecookie.GenerateRandomKey(32))
+	if err != nil {
+		t.Fatalf("expected NewManager to pass, but got %v", err)
+	}
+
+	now := time.Now().UTC()
+	got, err := roundTrip(t, m, m, Values{"user": "gopher", "age": 10, "login": now})
+	if err != nil {
+		t.Fatalf("expected Load to pass, but got %v", err)
+	}
+	if s, _ := got.String("user"); s != "gopher" {
+		t.Errorf("expected user gopher, but got %q", s)
+	}
+	if i, _ := got.Int("age"); i != 10 {
+		t.Errorf("expected age 10, but got %d", i)
+	}
+	if tm, _ := got.Time("login"); !tm.Equal(now) {
+		t.Errorf("expected login %v, but got %v", now, tm)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldHash, oldBlock := securecookie.GenerateRandomKey(32), securecookie.GenerateRandomKey(16)
+	old, err := NewManager(oldHash, oldBlock)
+	if err != nil {
+		t.Fatalf("expected NewManager to pass, but got %v", err)
+	}
+	rotated, err := NewManager(securecookie.GenerateRandomKey(32), securecookie.GenerateRandomKey(16), oldHash, oldBlock)
+	if err != nil {
+		t.Fatalf("expected NewManager to pass, but got %v", err)
+	}
+	other, err := NewManager(securecookie.GenerateRandomKey(32), nil)
+	if err != nil {
+		t.Fatalf("expected NewManager to pass, but got %v", err)
+	}
+
+	if _, err := roundTrip(t, old, rotated, Values{"foo": "bar"}); err != nil {
+		t.Errorf("expected a rotated manager to read old cookies, but got %v", err)
+	}
+	if _, err := roundTrip(t, old, other, Values{"foo": "bar"}); err == nil {
+		t.Errorf("expected a manager with different keys to fail")
+	}
+}
+
+func TestNewManagerErrors(t *testing.T) {
+	tests := []struct {
+		keys [][]byte
+		err  error
+	}{
+		{nil, ErrNoKeys},
+		{[][]byte{[]byte("short")}, ErrInvalidHashKey},
+		{[][]byte{securecookie.GenerateRandomKey(32), []byte("short")}, ErrInvalidBlockKey},
+	}
+
+	for i, tc := range tests {
+		if _, err := NewManager(tc.keys...); err != tc.err {
+			t.Errorf("test %d: expected %v, but got %v", i+1, tc.err, err)
+		}
+	}
+}
+
+func TestNewManagerFromConfig(t *testing.T) {
+	c := cfg.NewConfig()
+	c.SetSlice("SESSION_HASH_KEYS", []string{base64.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))})
+	c.SetSlice("SESSION_BLOCK_KEYS", []string{base64.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))})
+	c.SetString("SESSION_DOMAIN", "example.com")
+	c.SetString("SESSION_SAME_SITE", "strict")
+	c.SetDuration("SESSION_MAX_AGE", time.Hour)
+
+	m, err := NewManagerFromConfig(c)
+	if err != nil {
+		t.Fatalf("expected NewManagerFromConfig to pass, but got %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	if err := m.Save(w, "session", Values{"foo": "bar"}); err != nil {
+		t.Fatalf("expected Save to pass, but got %v", err)
+	}
+	ck := w.Result().Cookies()[0]
+	if ck.Domain != "example.com" || ck.MaxAge != 3600 || ck.SameSite != http.SameSiteStrictMode || !ck.HttpOnly {
+		t.Errorf("unexpected cookie attributes %+v", ck)
+	}
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a session doesn't exist or expired
+	ErrNotFound = errors.New("session not found")
+
+	// ErrInvalidID is returned for session ids which couldn't have been generated by NewID
+	ErrInvalidID = errors.New("invalid session id")
+)
+
+// Session contains a session and its metadata. With a StoreManager only the ID is sent to the client and
+// the remaining data stays in a Store.
+type Session struct {
+	ID         string
+	UserID     string
+	Values     Values
+	CreatedAt  time.Time
+	AccessedAt time.Time
+	ExpiresAt  time.Time
+}
+
+// Expired returns true if the session expired at the provided time
+func (s *Session) Expired(t time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !t.Before(s.ExpiresAt)
+}
+
+// Store is an interface for server side session storage backends
+type Store interface {
+	// Get returns the session with the provided id or ErrNotFound if it doesn't exist or expired
+	Get(ctx context.Context, id string) (*Session, error)
+
+	// Save creates or updates a session
+	Save(ctx context.Context, s *Session) error
+
+	// Delete removes the session with the provided id, deleting a non existing session isn't an error
+	Delete(ctx context.Context, id string) error
+
+	// DeleteUser removes all sessions belonging to the provided user id
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// NewID returns a random, URL safe session id containing 256 bits of entropy
+func NewID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// validID checks if id could have been generated by NewID, which makes it safe to use in keys and file names
+func validID(id string) bool {
+	if len(id) != base64.RawURLEncoding.EncodedLen(32) {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// copySession returns a copy of s, so stores don't share Values maps with their callers
+func copySession(s *Session) *Session {
+	c := *s
+	c.Values = make(Values, len(s.Values))
+	for k, v := range s.Values {
+		c.Values[k] = v
+	}
+	return &c
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+)
+
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	var ids []string
+	for _, user := range []string{"alice", "alice", "bob"} {
+		id, err := NewID()
+		if err != nil {
+			t.Fatalf("expected NewID to pass, but got %v", err)
+		}
+		sess := &Session{ID: id, UserID: user, Values: Values{"foo": "bar"}, ExpiresAt: time.Now().Add(time.Hour)}
+		if err := s.Save(ctx, sess); err != nil {
+			t.Fatalf("expected Save to pass, but got %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	got, err := s.Get(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("expected Get to pass, but got %v", err)
+	}
+	if v, _ := got.Values.String("foo"); v != "bar" || got.UserID != "alice" {
+		t.Errorf("unexpected session %+v", got)
+	}
+
+	if err := s.DeleteUser(ctx, "alice"); err != nil {
+		t.Fatalf("expected DeleteUser to pass, but got %v", err)
+	}
+	for i, id := range ids {
+		_, err := s.Get(ctx, id)
+		if i < 2 && err != ErrNotFound {
+			t.Errorf("expected session %d to be deleted, but got %v", i, err)
+		}
+		if i == 2 && err != nil {
+			t.Errorf("expected session %d to exist, but got %v", i, err)
+		}
+	}
+
+	expired := &Session{ID: ids[2], UserID: "bob", ExpiresAt: time.Now().Add(-time.Second)}
+	if err := s.Save(ctx, expired); err != nil {
+		t.Fatalf("expected Save to pass, but got %v", err)
+	}
+	if _, err := s.Get(ctx, ids[2]); err != ErrNotFound {
+		t.Errorf("expected an expired session to be not found, but got %v", err)
+	}
+
+	if err := s.Save(ctx, &Session{ID: "../../etc/passwd"}); err != ErrInvalidID {
+		t.Errorf("expected %v, but got %v", ErrInvalidID, err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore(time.Minute)
+	defer s.Close()
+	testStore(t, s)
+}
+
+func TestFileStore(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("expected NewFileStore to pass, but got %v", err)
+	}
+	testStore(t, s)
+}
+
+func TestStoreManager(t *testing.T) {
+	m, err := NewManager(securecookie.GenerateRandomKey(32), securecookie.GenerateRandomKey(32))
+	if err != nil {
+		t.Fatalf("expected NewManager to pass, but got %v", err)
+	}
+	store := NewMemoryStore(0)
+	sm := NewStoreManager(m, store, "sid")
+	ctx := context.Background()
+
+	request := func(w *httptest.ResponseRecorder) *http.Request {
+		r := httptest.NewRequest("GET", "/", nil)
+		for _, c := range w.Result().Cookies() {
+			r.AddCookie(c)
+		}
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	s, err := sm.New(ctx, w, "", Values{"cart": 3})
+	if err != nil {
+		t.Fatalf("expected New to pass, but got %v", err)
+	}
+	anonymous := s.ID
+
+	got, err := sm.Get(request(w))
+	if err != nil || got.ID != anonymous {
+		t.Fatalf("expected to get session %s, but got %v", anonymous, err)
+	}
+
+	w = httptest.NewRecorder()
+	if err := sm.Regenerate(ctx, w, got, "alice"); err != nil {
+		t.Fatalf("expected Regenerate to pass, but got %v", err)
+	}
+	if got.ID == anonymous {
+		t.Errorf("expected a new session id")
+	}
+	if _, err := store.Get(ctx, anonymous); err != ErrNotFound {
+		t.Errorf("expected the old session to be removed, but got %v", err)
+	}
+
+	got, err = sm.Get(request(w))
+	if err != nil {
+		t.Fatalf("expected Get to pass, but got %v", err)
+	}
+	if v, _ := got.Values.Int("cart"); v != 3 || got.UserID != "alice" {
+		t.Errorf("unexpected session %+v", got)
+	}
+
+	if err := sm.DestroyUser(ctx, "alice"); err != nil {
+		t.Fatalf("expected DestroyUser to pass, but got %v", err)
+	}
+	if _, err := sm.Get(request(w)); err != ErrNotFound {
+		t.Errorf("expected %v, but got %v", ErrNotFound, err)
+	}
+}
@@ -0,0 +1,231 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Algorithm is the name of a signature scheme. The hash function isn't part of the algorithm, it's determined by
+// the DocumentHash which is signed.
+type Algorithm string
+
+// Supported signature algorithms
+const (
+	RSAPKCS1v15 Algorithm = "RSA-PKCS1v15"
+	RSAPSS      Algorithm = "RSA-PSS"
+	ECDSAP256   Algorithm = "ECDSA-P256"
+	ECDSAP384   Algorithm = "ECDSA-P384"
+	Ed25519     Algorithm = "Ed25519"
+)
+
+// MinRSAKeySize is the smallest RSA key size which is accepted when generating keys
+const MinRSAKeySize = 2048
+
+// DefaultRSAKeySize is the size of RSA keys generated by CreateKeyPair and GenerateKey, use GenerateRSAKey or
+// CreateRSAKeyPair for other sizes
+const DefaultRSAKeySize = 2048
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signature algorithm")
+	ErrUnsupportedKey       = errors.New("unsupported key type")
+	ErrAlgorithmMismatch    = errors.New("key can't be used with this algorithm")
+	ErrInvalidSignature     = errors.New("invalid signature")
+)
+
+// Signer is a crypto.Signer which is bound to a signature algorithm. Functions in this package which accept a
+// crypto.Signer use the algorithm of a Signer, and the default algorithm of the key type for any other signer.
+type Signer interface {
+	crypto.Signer
+	Algorithm() Algorithm
+}
+
+// Verifier verifies signatures of document hashes. The algorithm is taken from the signature.
+type Verifier interface {
+	Verify(h DocumentHash, s Signature) error
+}
+
+// algorithmSigner binds a crypto.Signer to an algorithm
+type algorithmSigner struct {
+	crypto.Signer
+	alg Algorithm
+}
+
+// Algorithm implements the Signer interface
+func (s algorithmSigner) Algorithm() Algorithm {
+	return s.alg
+}
+
+// Sign implements the crypto.Signer interface and applies the options required by the algorithm
+func (s algorithmSigner) Sign(r io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	switch s.alg {
+	case RSAPSS:
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: opts.HashFunc()}
+	case Ed25519:
+		// Ed25519 signs the digest itself as the message
+		opts = crypto.Hash(0)
+	}
+	return s.Signer.Sign(r, digest, opts)
+}
+
+// NewSigner binds k to alg. When alg is empty the algorithm of a Signer or the default algorithm for the key type
+// is used.
+func NewSigner(k crypto.Signer, alg Algorithm) (Signer, error) {
+	if s, ok := k.(Signer); ok && alg == "" {
+		alg = s.Algorithm()
+	}
+	k = unwrap(k)
+	if alg == "" {
+		var err error
+		if alg, err = DefaultAlgorithm(k.Public()); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkKey(k.Public(), alg); err != nil {
+		return nil, err
+	}
+	return algorithmSigner{Signer: k, alg: alg}, nil
+}
+
+// signer returns k as a Signer, using the default algorithm unless k already is a Signer
+func signer(k crypto.Signer) (Signer, error) {
+	if s, ok := k.(Signer); ok {
+		return s, nil
+	}
+	return NewSigner(k, "")
+}
+
+// DefaultAlgorithm returns the algorithm used for keys which aren't bound to an algorithm. RSA keys use
+// PKCS #1 v1.5 for compatibility with signatures created by earlier versions of this package.
+func DefaultAlgorithm(k crypto.PublicKey) (Algorithm, error) {
+	switch t := k.(type) {
+	case *rsa.PublicKey:
+		return RSAPKCS1v15, nil
+	case *ecdsa.PublicKey:
+		switch t.Curve {
+		case elliptic.P256():
+			return ECDSAP256, nil
+		case elliptic.P384():
+			return ECDSAP384, nil
+		}
+	case ed25519.PublicKey, *ed25519.PublicKey:
+		return Ed25519, nil
+	}
+	return "", ErrUnsupportedKey
+}
+
+// checkKey checks if the public key k can be used with alg
+func checkKey(k crypto.PublicKey, alg Algorithm) error {
+	def, err := DefaultAlgorithm(k)
+	if err != nil {
+		return err
+	}
+
+	switch alg {
+	case RSAPKCS1v15, RSAPSS:
+		if def == RSAPKCS1v15 {
+			return nil
+		}
+	case ECDSAP256, ECDSAP384, Ed25519:
+		if def == alg {
+			return nil
+		}
+	default:
+		return ErrUnsupportedAlgorithm
+	}
+	return ErrAlgorithmMismatch
+}
+
+// GenerateKey generates a private key for the provided algorithm. RSA keys have a size of DefaultRSAKeySize.
+func GenerateKey(alg Algorithm) (Signer, error) {
+	var (
+		k   crypto.Signer
+		err error
+	)
+
+	switch alg {
+	case RSAPKCS1v15, RSAPSS:
+		return GenerateRSAKey(alg, DefaultRSAKeySize)
+	case ECDSAP256:
+		k, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case ECDSAP384:
+		k, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case Ed25519:
+		_, k, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+	return algorithmSigner{Signer: k, alg: alg}, nil
+}
+
+// GenerateRSAKey generates a RSA private key of the provided size for RSA-PKCS1v15 or RSA-PSS
+func GenerateRSAKey(alg Algorithm, bits int) (Signer, error) {
+	if alg != RSAPKCS1v15 && alg != RSAPSS {
+		return nil, fmt.Errorf("%w: %s isn't a RSA algorithm", ErrUnsupportedAlgorithm, alg)
+	}
+	k, _, err := CreateRSAKeyPair(bits)
+	if err != nil {
+		return nil, err
+	}
+	return algorithmSigner{Signer: k, alg: alg}, nil
+}
+
+// publicKeyVerifier verifies signatures with a public key
+type publicKeyVerifier struct {
+	k crypto.PublicKey
+}
+
+// NewVerifier returns a Verifier for the provided public key
+func NewVerifier(k crypto.PublicKey) (Verifier, error) {
+	if _, err := DefaultAlgorithm(k); err != nil {
+		return nil, err
+	}
+	return publicKeyVerifier{k: k}, nil
+}
+
+// Verify implements the Verifier interface
+func (v publicKeyVerifier) Verify(h DocumentHash, s Signature) error {
+	digest, hf, err := h.Digest()
+	if err != nil {
+		return err
+	}
+
+	alg, sig, err := s.Decode()
+	if err != nil {
+		return err
+	}
+	if err := checkKey(v.k, alg); err != nil {
+		return err
+	}
+
+	switch alg {
+	case RSAPKCS1v15:
+		return rsa.VerifyPKCS1v15(v.k.(*rsa.PublicKey), hf, digest, sig)
+	case RSAPSS:
+		return rsa.VerifyPSS(v.k.(*rsa.PublicKey), hf, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	case ECDSAP256, ECDSAP384:
+		if !ecdsa.VerifyASN1(v.k.(*ecdsa.PublicKey), digest, sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case Ed25519:
+		k, ok := v.k.(ed25519.PublicKey)
+		if !ok {
+			k = *v.k.(*ed25519.PublicKey)
+		}
+		if !ed25519.Verify(k, digest, sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+}
@@ -0,0 +1,110 @@
+package sign_test
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/arjanvaneersel/kit/sign"
+)
+
+var algorithms = []sign.Algorithm{sign.RSAPKCS1v15, sign.RSAPSS, sign.ECDSAP256, sign.ECDSAP384, sign.Ed25519}
+
+func TestAlgorithms(t *testing.T) {
+	h, err := sign.HashDocument(map[string]string{"title": "contract"})
+	if err != nil {
+		t.Fatalf("[FAIL] Expected HashDocument to pass, but got error %q.", err)
+	}
+	fh, err := sign.HashReader(strings.NewReader("artifact"), crypto.SHA512)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected HashReader to pass, but got error %q.", err)
+	}
+
+	for _, alg := range algorithms {
+		k, err := sign.GenerateKey(alg)
+		if err != nil {
+			t.Fatalf("[FAIL] %s: Expected GenerateKey to pass, but got error %q.", alg, err)
+		}
+		other, err := sign.GenerateKey(alg)
+		if err != nil {
+			t.Fatalf("[FAIL] %s: Expected GenerateKey to pass, but got error %q.", alg, err)
+		}
+
+		for _, dh := range []sign.DocumentHash{h, fh} {
+			s, err := sign.Sign(dh, k)
+			if err != nil {
+				t.Fatalf("[FAIL] %s: Expected Sign to pass, but got error %q.", alg, err)
+			}
+			if s.Algorithm() != alg {
+				t.Errorf("[FAIL] %s: Expected the signature to carry the algorithm, but got %s.", alg, s.Algorithm())
+			}
+
+			if err := sign.ValidSignature(dh, s, k.Public()); err != nil {
+				t.Errorf("[FAIL] %s: Expected signature to be valid, but got error %q.", alg, err)
+			}
+			if err := sign.ValidSignature(dh, s, other.Public()); err == nil {
+				t.Errorf("[FAIL] %s: Expected signature to be invalid for another key.", alg)
+			}
+		}
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	priv, _, err := sign.CreateKeyPair()
+	if err != nil {
+		t.Fatalf("[FAIL] Expected CreateKeyPair to pass, but got error %q.", err)
+	}
+
+	s, err := sign.NewSigner(priv, sign.RSAPSS)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected NewSigner to pass, but got error %q.", err)
+	}
+	if s.Algorithm() != sign.RSAPSS {
+		t.Errorf("[FAIL] Expected %s, but got %s.", sign.RSAPSS, s.Algorithm())
+	}
+
+	if _, err := sign.NewSigner(priv, sign.Ed25519); err != sign.ErrAlgorithmMismatch {
+		t.Errorf("[FAIL] Expected %q, but got %q.", sign.ErrAlgorithmMismatch, err)
+	}
+	if _, _, err := sign.CreateRSAKeyPair(1024); err == nil {
+		t.Errorf("[FAIL] Expected CreateRSAKeyPair to refuse 1024 bit keys.")
+	}
+}
+
+func TestGenerateRSAKey(t *testing.T) {
+	k, err := sign.GenerateRSAKey(sign.RSAPSS, 3072)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected GenerateRSAKey to pass, but got error %q.", err)
+	}
+	if bits := k.Public().(*rsa.PublicKey).N.BitLen(); bits != 3072 || k.Algorithm() != sign.RSAPSS {
+		t.Errorf("[FAIL] Expected a 3072 bit RSA-PSS key, but got %d bits and %s.", bits, k.Algorithm())
+	}
+
+	if _, err := sign.GenerateRSAKey(sign.Ed25519, 2048); !errors.Is(err, sign.ErrUnsupportedAlgorithm) {
+		t.Errorf("[FAIL] Expected %q, but got %q.", sign.ErrUnsupportedAlgorithm, err)
+	}
+	if _, err := sign.GenerateRSAKey(sign.RSAPKCS1v15, 1024); err == nil {
+		t.Errorf("[FAIL] Expected GenerateRSAKey to refuse 1024 bit keys.")
+	}
+}
+
+func TestLegacySignature(t *testing.T) {
+	priv, pub, err := sign.CreateKeyPair()
+	if err != nil {
+		t.Fatalf("[FAIL] Expected CreateKeyPair to pass, but got error %q.", err)
+	}
+	h, _ := sign.HashDocument("legacy")
+
+	s, err := sign.Sign(h, priv)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected Sign to pass, but got error %q.", err)
+	}
+
+	// Signatures created before algorithms were recorded are plain hex RSA PKCS #1 v1.5 signatures
+	legacy := sign.Signature(strings.TrimPrefix(string(s), string(sign.RSAPKCS1v15)+":"))
+	if err := sign.ValidSignature(h, legacy, pub); err != nil {
+		t.Errorf("[FAIL] Expected legacy signature to be valid, but got error %q.", err)
+	}
+}
@@ -0,0 +1,249 @@
+package sign
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxExactInt is the largest integer which can be represented exactly by a float64
+const maxExactInt = 1 << 53
+
+// Canonicalize returns the canonical JSON encoding (RFC 8785) of v. Values are first encoded with encoding/json,
+// so json struct tags and Marshaler implementations are respected. Object keys are sorted, insignificant
+// whitespace is removed and numbers and strings are serialized in their shortest canonical form.
+// Integers which can't be represented exactly by a float64, structs which have fields but no exported ones,
+// channels, functions, complex numbers, NaN and infinity result in an error.
+func Canonicalize(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFields(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// checkFields returns an error for structs which have fields, but none which encoding/json encodes. They would
+// be encoded as {}, so a signature wouldn't cover their content.
+func checkFields(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return checkFields(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkFields(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := checkFields(iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		if marshals(t) {
+			return nil
+		}
+		if t.NumField() > 0 && !hasExportedFields(t) {
+			return fmt.Errorf("canonical json: %s has no exported fields", t)
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() || f.Anonymous {
+				if err := checkFields(v.Field(i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// marshals reports if t encodes itself
+func marshals(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return t.Implements(jsonMarshaler) || pt.Implements(jsonMarshaler) || t.Implements(textMarshaler) || pt.Implements(textMarshaler)
+}
+
+// hasExportedFields reports if t has an exported field, including the promoted fields of embedded structs
+func hasExportedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.IsExported() {
+			return true
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && hasExportedFields(ft) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+	case string:
+		writeString(buf, t)
+	case json.Number:
+		return writeNumber(buf, t)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		// RFC 8785 sorts keys by their UTF-16 code units
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeString(buf, k)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, t[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("canonical json: unsupported type %T", v)
+	}
+	return nil
+}
+
+// writeNumber writes numbers as ECMAScript would, which is also the way encoding/json formats a float64.
+// Integers which a float64 can't represent exactly are rejected, as RFC 8785 would silently round them.
+func writeNumber(buf *bytes.Buffer, n json.Number) error {
+	if !strings.ContainsAny(string(n), ".eE") {
+		i, ok := new(big.Int).SetString(string(n), 10)
+		if !ok {
+			return fmt.Errorf("canonical json: invalid number %s", n)
+		}
+		f, acc := new(big.Float).SetInt(i).Float64()
+		if acc != big.Exact {
+			return fmt.Errorf("canonical json: integer %s can't be represented exactly by a float64", n)
+		}
+		return writeFloat(buf, f)
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	return writeFloat(buf, f)
+}
+
+// writeFloat writes f in its ECMAScript form
+func writeFloat(buf *bytes.Buffer, f float64) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return fmt.Errorf("canonical json: unsupported number %v", f)
+	}
+	if f == math.Trunc(f) && math.Abs(f) < maxExactInt {
+		// 1.0 and -0 are both integers in canonical form
+		buf.WriteString(strconv.FormatInt(int64(f), 10))
+		return nil
+	}
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+// writeString writes a JSON string, only escaping what RFC 8785 requires
+func writeString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[r>>4])
+				buf.WriteByte(hex[r&0xf])
+				continue
+			}
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// lessUTF16 compares two strings by their UTF-16 code units
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
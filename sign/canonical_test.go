@@ -0,0 +1,102 @@
+package sign_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjanvaneersel/kit/sign"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		v        interface{}
+		expected string
+	}{
+		{"foo", `"foo"`},
+		{[]string{"a", "b"}, `["a","b"]`},
+		{map[string]interface{}{"b": 1, "a": []int{2, 3}}, `{"a":[2,3],"b":1}`},
+		{struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		}{"Go Pher", 40}, `{"age":40,"name":"Go Pher"}`},
+		{1.0, `1`},
+		{0.1, `0.1`},
+		{1e21, `1e+21`},
+		{uint64(1 << 63), `9223372036854776000`},
+		{int64(-1 << 53), `-9007199254740992`},
+		{"< >\x01", "\"< >\\u0001\""},
+		{map[string]int{"\uFB33": 1, "\U0001F600": 2, "a": 3}, "{\"a\":3,\"\U0001F600\":2,\"\uFB33\":1}"},
+		{nil, `null`},
+	}
+
+	for i, tc := range tests {
+		got, err := sign.Canonicalize(tc.v)
+		if err != nil {
+			t.Fatalf("[FAIL] Test %d: Expected Canonicalize to pass, but got error %q.", i+1, err)
+		}
+		if string(got) != tc.expected {
+			t.Errorf("[FAIL] Test %d: Expected %s, but got %s.", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestCanonicalizeUnsupported(t *testing.T) {
+	for i, v := range []interface{}{make(chan int), func() {}, complex(1, 2), uint64(1<<64 - 1), int64(1<<53 + 1)} {
+		if _, err := sign.Canonicalize(v); err == nil {
+			t.Errorf("[FAIL] Test %d: Expected Canonicalize to fail for %T.", i+1, v)
+		}
+	}
+}
+
+func TestCanonicalizeUnexported(t *testing.T) {
+	type secret struct {
+		name string
+	}
+	type embedded struct {
+		secret
+	}
+	for i, v := range []interface{}{secret{"x"}, &secret{"x"}, []interface{}{secret{}}, map[string]embedded{"a": {}}} {
+		if _, err := sign.Canonicalize(v); err == nil {
+			t.Errorf("[FAIL] Test %d: Expected Canonicalize to fail for %T.", i+1, v)
+		}
+	}
+
+	type Public struct {
+		Name string `json:"name"`
+	}
+	type promoted struct {
+		Public
+		note string
+	}
+	for i, v := range []interface{}{struct{}{}, promoted{Public{"x"}, "y"}, time.Unix(0, 0)} {
+		if _, err := sign.Canonicalize(v); err != nil {
+			t.Errorf("[FAIL] Test %d: Expected Canonicalize to pass for %T, but got error %q.", i+1, v, err)
+		}
+	}
+}
+
+func TestHashDocumentDeterministic(t *testing.T) {
+	a, err := sign.HashDocument(map[string]interface{}{"a": "x", "b": []string{"y", "z"}})
+	if err != nil {
+		t.Fatalf("[FAIL] Expected HashDocument to pass, but got error %q.", err)
+	}
+	b, err := sign.HashDocument(map[string]interface{}{"b": []string{"y", "z"}, "a": "x"})
+	if err != nil {
+		t.Fatalf("[FAIL] Expected HashDocument to pass, but got error %q.", err)
+	}
+	c, err := sign.HashDocument("another document")
+	if err != nil {
+		t.Fatalf("[FAIL] Expected HashDocument to pass, but got error %q.", err)
+	}
+
+	if a != b {
+		t.Errorf("[FAIL] Expected equal documents to have equal hashes, but got %s and %s.", a, b)
+	}
+	if a == c {
+		t.Errorf("[FAIL] Expected different documents to have different hashes.")
+	}
+	if !strings.HasPrefix(string(a), sign.HashFormatV1+":") || a.Format() != sign.HashFormatV1 {
+		t.Errorf("[FAIL] Expected hash %s to have format %s.", a, sign.HashFormatV1)
+	}
+}
@@ -0,0 +1,208 @@
+package sign
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrAlreadySigned    = errors.New("envelope is already signed with this key")
+	ErrNoSignature      = errors.New("envelope has no signature for this key")
+	ErrThresholdNotMet  = errors.New("not enough valid signatures")
+	ErrInvalidThreshold = errors.New("threshold must be between 1 and the number of keys")
+	ErrDocumentMismatch = errors.New("envelope doesn't cover the document")
+)
+
+// EnvelopeSignature is the signature of one party on an Envelope
+type EnvelopeSignature struct {
+	KeyID     string    `json:"key_id"`
+	Algorithm Algorithm `json:"algorithm"`
+	SignedAt  time.Time `json:"signed_at"`
+	Signature Signature `json:"signature"`
+}
+
+// Envelope wraps the hash of a payload with the signatures of one or more parties. Every signature covers the
+// payload hash, the content type, the creation time of the envelope and the signer's key id and signing time.
+type Envelope struct {
+	Hash        DocumentHash        `json:"hash"`
+	ContentType string              `json:"content_type,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	Signatures  []EnvelopeSignature `json:"signatures"`
+}
+
+// statement is the data which is signed by every party
+type statement struct {
+	Hash        DocumentHash `json:"hash"`
+	ContentType string       `json:"content_type"`
+	CreatedAt   time.Time    `json:"created_at"`
+	KeyID       string       `json:"key_id"`
+	Algorithm   Algorithm    `json:"algorithm"`
+	SignedAt    time.Time    `json:"signed_at"`
+}
+
+// NewEnvelope returns an unsigned envelope for the payload hash h
+func NewEnvelope(h DocumentHash, contentType string) *Envelope {
+	return &Envelope{
+		Hash:        h,
+		ContentType: contentType,
+		CreatedAt:   time.Now().UTC(),
+	}
+}
+
+// ParseEnvelope parses a JSON encoded envelope
+func ParseEnvelope(b []byte) (*Envelope, error) {
+	var e Envelope
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, err
+	}
+	if _, _, err := e.Hash.Digest(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Marshal returns the JSON encoding of the envelope
+func (e *Envelope) Marshal() ([]byte, error) {
+	return json.MarshalIndent(e, "", "  ")
+}
+
+func (e *Envelope) statementHash(s EnvelopeSignature) (DocumentHash, error) {
+	return HashDocument(statement{
+		Hash:        e.Hash,
+		ContentType: e.ContentType,
+		CreatedAt:   e.CreatedAt,
+		KeyID:       s.KeyID,
+		Algorithm:   s.Algorithm,
+		SignedAt:    s.SignedAt,
+	})
+}
+
+// Sign adds the signature of k to the envelope
+func (e *Envelope) Sign(k crypto.Signer) error {
+	s, err := signer(k)
+	if err != nil {
+		return err
+	}
+
+	id, err := KeyID(k.Public())
+	if err != nil {
+		return err
+	}
+	if _, ok := e.signature(id); ok {
+		return ErrAlreadySigned
+	}
+
+	es := EnvelopeSignature{
+		KeyID:     id,
+		Algorithm: s.Algorithm(),
+		SignedAt:  time.Now().UTC(),
+	}
+	h, err := e.statementHash(es)
+	if err != nil {
+		return err
+	}
+	if es.Signature, err = Sign(h, s); err != nil {
+		return err
+	}
+
+	e.Signatures = append(e.Signatures, es)
+	return nil
+}
+
+func (e *Envelope) signature(id string) (EnvelopeSignature, bool) {
+	for _, s := range e.Signatures {
+		if s.KeyID == id {
+			return s, true
+		}
+	}
+	return EnvelopeSignature{}, false
+}
+
+// Verify checks the signature of the public key k on the envelope
+func (e *Envelope) Verify(k crypto.PublicKey) error {
+	id, err := KeyID(k)
+	if err != nil {
+		return err
+	}
+
+	s, ok := e.signature(id)
+	if !ok {
+		return ErrNoSignature
+	}
+	if s.Signature.Algorithm() != s.Algorithm {
+		return fmt.Errorf("%w: algorithm %s doesn't match the signature", ErrAlgorithmMismatch, s.Algorithm)
+	}
+
+	h, err := e.statementHash(s)
+	if err != nil {
+		return err
+	}
+	return ValidSignature(h, s.Signature, k)
+}
+
+// VerifyDocument checks if the envelope covers the document d, hashed with HashDocument, and satisfies policy p
+func (e *Envelope) VerifyDocument(d interface{}, p *Policy) ([]string, error) {
+	h, err := HashDocument(d)
+	if err != nil {
+		return nil, err
+	}
+	if h != e.Hash {
+		return nil, ErrDocumentMismatch
+	}
+	return p.Verify(e)
+}
+
+// Policy requires valid signatures of at least Threshold of its keys, i.e. "2 of these 3 keys"
+type Policy struct {
+	Threshold int
+	keys      map[string]crypto.PublicKey
+}
+
+// NewPolicy returns a Policy which requires threshold signatures of the provided keys
+func NewPolicy(threshold int, keys ...crypto.PublicKey) (*Policy, error) {
+	p := Policy{Threshold: threshold, keys: make(map[string]crypto.PublicKey)}
+	for _, k := range keys {
+		id, err := KeyID(k)
+		if err != nil {
+			return nil, err
+		}
+		p.keys[id] = k
+	}
+
+	if threshold < 1 || threshold > len(p.keys) {
+		return nil, ErrInvalidThreshold
+	}
+	return &p, nil
+}
+
+// Verify checks the envelope against the policy and returns the ids of the keys with a valid signature.
+// Signatures of keys which aren't part of the policy are ignored.
+func (p *Policy) Verify(e *Envelope) ([]string, error) {
+	var valid []string
+	for _, s := range e.Signatures {
+		k, ok := p.keys[s.KeyID]
+		if !ok || contains(valid, s.KeyID) {
+			continue
+		}
+		if err := e.Verify(k); err == nil {
+			valid = append(valid, s.KeyID)
+		}
+	}
+
+	if len(valid) < p.Threshold {
+		return valid, fmt.Errorf("%w: %d of %d required", ErrThresholdNotMet, len(valid), p.Threshold)
+	}
+	return valid, nil
+}
+
+func contains(s []string, v string) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package sign_test
+
+import (
+	"crypto"
+	"errors"
+	"testing"
+
+	"github.com/arjanvaneersel/kit/sign"
+)
+
+func TestEnvelope(t *testing.T) {
+	doc := map[string]string{"title": "contract", "version": "3"}
+	h, err := sign.HashDocument(doc)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected HashDocument to pass, but got error %q.", err)
+	}
+
+	var keys []crypto.PublicKey
+	var signers []sign.Signer
+	for _, alg := range []sign.Algorithm{sign.Ed25519, sign.ECDSAP256, sign.RSAPSS} {
+		k, err := sign.GenerateKey(alg)
+		if err != nil {
+			t.Fatalf("[FAIL] Expected GenerateKey to pass, but got error %q.", err)
+		}
+		signers = append(signers, k)
+		keys = append(keys, k.Public())
+	}
+
+	policy, err := sign.NewPolicy(2, keys...)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected NewPolicy to pass, but got error %q.", err)
+	}
+
+	e := sign.NewEnvelope(h, "application/json")
+	if err := e.Sign(signers[0]); err != nil {
+		t.Fatalf("[FAIL] Expected Sign to pass, but got error %q.", err)
+	}
+	if err := e.Sign(signers[0]); err != sign.ErrAlreadySigned {
+		t.Errorf("[FAIL] Expected %q, but got %q.", sign.ErrAlreadySigned, err)
+	}
+	if _, err := policy.Verify(e); !errors.Is(err, sign.ErrThresholdNotMet) {
+		t.Errorf("[FAIL] Expected %q, but got %q.", sign.ErrThresholdNotMet, err)
+	}
+
+	if err := e.Sign(signers[2]); err != nil {
+		t.Fatalf("[FAIL] Expected Sign to pass, but got error %q.", err)
+	}
+
+	// Round trip through JSON, as the envelope would be passed between the parties
+	b, err := e.Marshal()
+	if err != nil {
+		t.Fatalf("[FAIL] Expected Marshal to pass, but got error %q.", err)
+	}
+	e, err = sign.ParseEnvelope(b)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected ParseEnvelope to pass, but got error %q.", err)
+	}
+
+	valid, err := e.VerifyDocument(doc, policy)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected VerifyDocument to pass, but got error %q.", err)
+	}
+	if len(valid) != 2 {
+		t.Errorf("[FAIL] Expected 2 valid signatures, but got %d.", len(valid))
+	}
+
+	if _, err := e.VerifyDocument(map[string]string{"title": "forged"}, policy); err != sign.ErrDocumentMismatch {
+		t.Errorf("[FAIL] Expected %q, but got %q.", sign.ErrDocumentMismatch, err)
+	}
+
+	// Moving a signature in time must invalidate it
+	e.Signatures[0].SignedAt = e.Signatures[0].SignedAt.Add(-1)
+	if err := e.Verify(keys[0]); err == nil {
+		t.Errorf("[FAIL] Expected a tampered signing time to be detected.")
+	}
+	if _, err := policy.Verify(e); !errors.Is(err, sign.ErrThresholdNotMet) {
+		t.Errorf("[FAIL] Expected %q, but got %q.", sign.ErrThresholdNotMet, err)
+	}
+}
+
+func TestEnvelopeTamperedAlgorithm(t *testing.T) {
+	h, _ := sign.HashDocument("contract")
+	k, err := sign.GenerateKey(sign.Ed25519)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected GenerateKey to pass, but got error %q.", err)
+	}
+
+	e := sign.NewEnvelope(h, "")
+	if err := e.Sign(k); err != nil {
+		t.Fatalf("[FAIL] Expected Sign to pass, but got error %q.", err)
+	}
+	e.Signatures[0].Algorithm = sign.ECDSAP256
+	if err := e.Verify(k.Public()); !errors.Is(err, sign.ErrAlgorithmMismatch) {
+		t.Errorf("[FAIL] Expected %q, but got %q.", sign.ErrAlgorithmMismatch, err)
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	k, _ := sign.GenerateKey(sign.Ed25519)
+	for _, threshold := range []int{0, 2} {
+		if _, err := sign.NewPolicy(threshold, k.Public()); err != sign.ErrInvalidThreshold {
+			t.Errorf("[FAIL] Threshold %d: Expected %q, but got %q.", threshold, sign.ErrInvalidThreshold, err)
+		}
+	}
+}
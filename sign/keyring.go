@@ -0,0 +1,141 @@
+package sign
+
+import (
+	"crypto"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/arjanvaneersel/kit/fo"
+)
+
+// File extensions used by a Keyring
+const (
+	publicKeyExt  = ".pub"
+	privateKeyExt = ".key"
+)
+
+var (
+	ErrKeyNotFound  = errors.New("key not found")
+	ErrAmbiguousKey = errors.New("key id prefix matches multiple keys")
+)
+
+// Keyring is a directory of keys, stored as <key id>.pub and <key id>.key PEM files
+type Keyring struct {
+	Dir string
+}
+
+// OpenKeyring returns a Keyring for dir, the directory will be created if it doesn't exist
+func OpenKeyring(dir string) (*Keyring, error) {
+	if err := fo.CreateDirectoryIfNotExist(dir); err != nil {
+		return nil, err
+	}
+	return &Keyring{Dir: dir}, nil
+}
+
+// AddPublicKey stores the public key and returns its key id
+func (kr *Keyring) AddPublicKey(k crypto.PublicKey) (string, error) {
+	id, err := KeyID(k)
+	if err != nil {
+		return "", err
+	}
+	return id, SavePublicKey(filepath.Join(kr.Dir, id+publicKeyExt), k)
+}
+
+// AddPrivateKey stores the private key, encrypted with password unless it's empty, together with its public key
+// and returns the key id
+func (kr *Keyring) AddPrivateKey(k crypto.Signer, password []byte) (string, error) {
+	id, err := kr.AddPublicKey(k.Public())
+	if err != nil {
+		return "", err
+	}
+	return id, SavePrivateKey(filepath.Join(kr.Dir, id+privateKeyExt), k, password)
+}
+
+// List returns the ids of all keys in the keyring
+func (kr *Keyring) List() ([]string, error) {
+	files, err := ioutil.ReadDir(kr.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), publicKeyExt) {
+			ids = append(ids, strings.TrimSuffix(f.Name(), publicKeyExt))
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// resolve finds the full key id for id, which may be a unique prefix of a key id
+func (kr *Keyring) resolve(id string) (string, error) {
+	id = strings.ToLower(id)
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", ErrKeyNotFound
+	}
+
+	ids, err := kr.List()
+	if err != nil {
+		return "", err
+	}
+
+	var found string
+	for _, i := range ids {
+		if i == id {
+			return i, nil
+		}
+		if strings.HasPrefix(i, id) {
+			if found != "" {
+				return "", ErrAmbiguousKey
+			}
+			found = i
+		}
+	}
+	if found == "" {
+		return "", ErrKeyNotFound
+	}
+	return found, nil
+}
+
+// PublicKey returns the public key with the provided id, which may be a unique prefix of the key id
+func (kr *Keyring) PublicKey(id string) (crypto.PublicKey, error) {
+	id, err := kr.resolve(id)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPublicKey(filepath.Join(kr.Dir, id+publicKeyExt))
+}
+
+// PrivateKey returns the private key with the provided id, which may be a unique prefix of the key id
+func (kr *Keyring) PrivateKey(id string, password []byte) (crypto.Signer, error) {
+	id, err := kr.resolve(id)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := LoadPrivateKey(filepath.Join(kr.Dir, id+privateKeyExt), password)
+	if os.IsNotExist(err) {
+		return nil, ErrKeyNotFound
+	}
+	return k, err
+}
+
+// Remove deletes the public and private key with the provided id
+func (kr *Keyring) Remove(id string) error {
+	id, err := kr.resolve(id)
+	if err != nil {
+		return err
+	}
+
+	for _, ext := range []string{privateKeyExt, publicKeyExt} {
+		if err := os.Remove(filepath.Join(kr.Dir, id+ext)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// PEM block types used for keys
+const (
+	PEMPrivateKey          = "PRIVATE KEY"
+	PEMEncryptedPrivateKey = "ENCRYPTED PRIVATE KEY"
+	PEMRSAPrivateKey       = "RSA PRIVATE KEY"
+	PEMECPrivateKey        = "EC PRIVATE KEY"
+	PEMPublicKey           = "PUBLIC KEY"
+	PEMRSAPublicKey        = "RSA PUBLIC KEY"
+)
+
+// PEMAlgorithmHeader is the PEM header which stores the algorithm of private keys bound to an algorithm other
+// than the default algorithm of the key type, i.e. RSA-PSS keys
+const PEMAlgorithmHeader = "Algorithm"
+
+var (
+	ErrNoPEMBlock       = errors.New("no PEM block found")
+	ErrPasswordRequired = errors.New("private key is encrypted, a password is required")
+)
+
+// KeyID returns an identifier for a public key, which is the hex encoded SHA-256 hash of its PKIX encoding
+func KeyID(k crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(k)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(der)), nil
+}
+
+// Fingerprint returns the fingerprint of a public key in the format used by OpenSSH, i.e. "SHA256:jX3k...",
+// which is the base64 encoded SHA-256 hash of its PKIX encoding
+func Fingerprint(k crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(k)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+// unwrap returns the key wrapped by a Signer created by this package, because x509 only knows the standard types
+func unwrap(k crypto.Signer) crypto.Signer {
+	if s, ok := k.(algorithmSigner); ok {
+		return s.Signer
+	}
+	return k
+}
+
+// MarshalPrivateKey returns the PEM encoded PKCS #8 form of the private key. When password isn't empty the key
+// is encrypted with it. The algorithm of a Signer is stored in the PEMAlgorithmHeader when it isn't the default
+// algorithm of the key type. Other keys have no headers, as OpenSSL doesn't accept them for PKCS #8 keys.
+func MarshalPrivateKey(k crypto.Signer, password []byte) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(unwrap(k))
+	if err != nil {
+		return nil, err
+	}
+
+	block := &pem.Block{Type: PEMPrivateKey, Bytes: der}
+	if s, ok := k.(Signer); ok {
+		def, err := DefaultAlgorithm(k.Public())
+		if err != nil {
+			return nil, err
+		}
+		if s.Algorithm() != def {
+			block.Headers = map[string]string{PEMAlgorithmHeader: string(s.Algorithm())}
+		}
+	}
+
+	if len(password) > 0 {
+		if block.Bytes, err = encryptPKCS8(der, password); err != nil {
+			return nil, err
+		}
+		block.Type = PEMEncryptedPrivateKey
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// MarshalPKCS1PrivateKey returns the PEM encoded PKCS #1 form of a RSA private key
+func MarshalPKCS1PrivateKey(k *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: PEMRSAPrivateKey, Bytes: x509.MarshalPKCS1PrivateKey(k)})
+}
+
+// MarshalPublicKey returns the PEM encoded PKIX form of the public key
+func MarshalPublicKey(k crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(k)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMPublicKey, Bytes: der}), nil
+}
+
+// ParsePrivateKey parses the first PEM encoded private key in b. PKCS #1 RSA keys, SEC 1 EC keys, PKCS #8 keys
+// and encrypted PKCS #8 keys are supported. The password is only used for encrypted keys. A key with a
+// PEMAlgorithmHeader is returned as a Signer bound to that algorithm, other keys use the default algorithm of
+// their type unless the caller binds them with NewSigner.
+func ParsePrivateKey(b, password []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, ErrNoPEMBlock
+	}
+
+	var (
+		k   interface{}
+		err error
+	)
+	switch block.Type {
+	case PEMRSAPrivateKey:
+		k, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case PEMECPrivateKey:
+		k, err = x509.ParseECPrivateKey(block.Bytes)
+	case PEMPrivateKey:
+		k, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case PEMEncryptedPrivateKey:
+		if len(password) == 0 {
+			return nil, ErrPasswordRequired
+		}
+		der, derr := decryptPKCS8(block.Bytes, password)
+		if derr != nil {
+			return nil, derr
+		}
+		if k, err = x509.ParsePKCS8PrivateKey(der); err != nil {
+			// A wrong password may result in valid padding, but never in a valid key
+			return nil, ErrIncorrectPassword
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var signer crypto.Signer
+	switch t := k.(type) {
+	case *rsa.PrivateKey:
+		signer = t
+	case *ecdsa.PrivateKey:
+		signer = t
+	case ed25519.PrivateKey:
+		signer = t
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	if alg, ok := block.Headers[PEMAlgorithmHeader]; ok {
+		return NewSigner(signer, Algorithm(alg))
+	}
+	return signer, nil
+}
+
+// ParsePublicKey parses the first PEM encoded public key in b. PKIX and PKCS #1 RSA public keys are supported.
+func ParsePublicKey(b []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, ErrNoPEMBlock
+	}
+
+	var (
+		k   interface{}
+		err error
+	)
+	switch block.Type {
+	case PEMPublicKey:
+		k, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case PEMRSAPublicKey:
+		k, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := DefaultAlgorithm(k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// SavePrivateKey writes the private key to the file at path, readable only by the owner, see MarshalPrivateKey
+func SavePrivateKey(path string, k crypto.Signer, password []byte) error {
+	b, err := MarshalPrivateKey(k, password)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// LoadPrivateKey reads the private key from the file at path, see ParsePrivateKey
+func LoadPrivateKey(path string, password []byte) (crypto.Signer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(b, password)
+}
+
+// SavePublicKey writes the public key to the file at path
+func SavePublicKey(path string, k crypto.PublicKey) error {
+	b, err := MarshalPublicKey(k)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// LoadPublicKey reads the public key from the file at path, see ParsePublicKey
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(b)
+}
@@ -0,0 +1,148 @@
+package sign_test
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arjanvaneersel/kit/sign"
+)
+
+func init() {
+	// Keep the tests fast, the default is meant to slow down attackers
+	sign.KDFIterations = 1000
+}
+
+func TestPrivateKeyPEM(t *testing.T) {
+	password := []byte("correct horse battery staple")
+	h, _ := sign.HashDocument("pem")
+
+	for _, alg := range algorithms {
+		k, err := sign.GenerateKey(alg)
+		if err != nil {
+			t.Fatalf("[FAIL] %s: Expected GenerateKey to pass, but got error %q.", alg, err)
+		}
+
+		for _, pw := range [][]byte{nil, password} {
+			b, err := sign.MarshalPrivateKey(k, pw)
+			if err != nil {
+				t.Fatalf("[FAIL] %s: Expected MarshalPrivateKey to pass, but got error %q.", alg, err)
+			}
+
+			if strings.Contains(string(b), sign.PEMAlgorithmHeader) != (alg == sign.RSAPSS) {
+				t.Errorf("[FAIL] %s: Expected only keys with a non default algorithm to have a header.", alg)
+			}
+
+			parsed, err := sign.ParsePrivateKey(b, pw)
+			if err != nil {
+				t.Fatalf("[FAIL] %s: Expected ParsePrivateKey to pass, but got error %q.", alg, err)
+			}
+
+			s, err := sign.Sign(h, parsed)
+			if err != nil {
+				t.Fatalf("[FAIL] %s: Expected Sign to pass, but got error %q.", alg, err)
+			}
+			if sa, _, _ := s.Decode(); sa != alg {
+				t.Errorf("[FAIL] %s: Expected the parsed key to keep its algorithm, but got %q.", alg, sa)
+			}
+			if err := sign.ValidSignature(h, s, k.Public()); err != nil {
+				t.Errorf("[FAIL] %s: Expected the parsed key to match, but got error %q.", alg, err)
+			}
+		}
+
+		b, _ := sign.MarshalPrivateKey(k, password)
+		if !strings.Contains(string(b), sign.PEMEncryptedPrivateKey) {
+			t.Errorf("[FAIL] %s: Expected an encrypted PEM block.", alg)
+		}
+		if _, err := sign.ParsePrivateKey(b, nil); err != sign.ErrPasswordRequired {
+			t.Errorf("[FAIL] %s: Expected %q, but got %q.", alg, sign.ErrPasswordRequired, err)
+		}
+		if _, err := sign.ParsePrivateKey(b, []byte("wrong")); err != sign.ErrIncorrectPassword {
+			t.Errorf("[FAIL] %s: Expected %q, but got %q.", alg, sign.ErrIncorrectPassword, err)
+		}
+	}
+}
+
+func TestPKCS1PEM(t *testing.T) {
+	priv, pub, err := sign.CreateKeyPair()
+	if err != nil {
+		t.Fatalf("[FAIL] Expected CreateKeyPair to pass, but got error %q.", err)
+	}
+
+	k, err := sign.ParsePrivateKey(sign.MarshalPKCS1PrivateKey(priv), nil)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected ParsePrivateKey to pass, but got error %q.", err)
+	}
+	if !k.(*rsa.PrivateKey).Equal(priv) {
+		t.Errorf("[FAIL] Expected the parsed key to be equal.")
+	}
+
+	b, err := sign.MarshalPublicKey(pub)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected MarshalPublicKey to pass, but got error %q.", err)
+	}
+	p, err := sign.ParsePublicKey(b)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected ParsePublicKey to pass, but got error %q.", err)
+	}
+	if !p.(*rsa.PublicKey).Equal(pub) {
+		t.Errorf("[FAIL] Expected the parsed public key to be equal.")
+	}
+}
+
+func TestKeyring(t *testing.T) {
+	kr, err := sign.OpenKeyring(filepath.Join(t.TempDir(), "keys"))
+	if err != nil {
+		t.Fatalf("[FAIL] Expected OpenKeyring to pass, but got error %q.", err)
+	}
+
+	k, err := sign.GenerateKey(sign.Ed25519)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected GenerateKey to pass, but got error %q.", err)
+	}
+	id, err := kr.AddPrivateKey(k, []byte("secret"))
+	if err != nil {
+		t.Fatalf("[FAIL] Expected AddPrivateKey to pass, but got error %q.", err)
+	}
+
+	ids, err := kr.List()
+	if err != nil || len(ids) != 1 || ids[0] != id {
+		t.Fatalf("[FAIL] Expected List to return [%s], but got %v (%v).", id, ids, err)
+	}
+
+	pub, err := kr.PublicKey(id[:8])
+	if err != nil {
+		t.Fatalf("[FAIL] Expected PublicKey to find the key by prefix, but got error %q.", err)
+	}
+	if got, _ := sign.KeyID(pub); got != id {
+		t.Errorf("[FAIL] Expected key id %s, but got %s.", id, got)
+	}
+
+	priv, err := kr.PrivateKey(id, []byte("secret"))
+	if err != nil {
+		t.Fatalf("[FAIL] Expected PrivateKey to pass, but got error %q.", err)
+	}
+	if !priv.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(pub) {
+		t.Errorf("[FAIL] Expected the private key to match the public key.")
+	}
+
+	if err := kr.Remove(id); err != nil {
+		t.Fatalf("[FAIL] Expected Remove to pass, but got error %q.", err)
+	}
+	if _, err := kr.PublicKey(id); err != sign.ErrKeyNotFound {
+		t.Errorf("[FAIL] Expected %q, but got %q.", sign.ErrKeyNotFound, err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	k, _ := sign.GenerateKey(sign.ECDSAP256)
+	fp, err := sign.Fingerprint(k.Public())
+	if err != nil {
+		t.Fatalf("[FAIL] Expected Fingerprint to pass, but got error %q.", err)
+	}
+	if !strings.HasPrefix(fp, "SHA256:") || len(fp) != 50 {
+		t.Errorf("[FAIL] Unexpected fingerprint %s.", fp)
+	}
+}
@@ -0,0 +1,355 @@
+package merkle
+
+import (
+	"bufio"
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arjanvaneersel/kit/sign"
+)
+
+var ErrKeyMismatch = errors.New("tree head was signed with a different key")
+
+// Log is an append-only log of document hashes
+type Log struct {
+	mu     sync.RWMutex
+	hashes []sign.DocumentHash
+	leaves [][]byte
+	peaks  [][]byte // roots of the perfect subtrees of the log, largest first
+	file   *os.File
+}
+
+// NewLog returns an empty, in-memory Log
+func NewLog() *Log {
+	return &Log{}
+}
+
+// OpenLog returns a Log which is persisted in the file at path, one document hash per line.
+// Existing records are loaded from the file, new records are appended and synced to disk.
+// A final record which was only partially written, e.g. because of a crash during Append, is
+// truncated from the file.
+func OpenLog(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	l := Log{file: f}
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		s, err := r.ReadString('\n')
+		if err == io.EOF {
+			if s != "" {
+				err = truncate(f, offset)
+			} else {
+				err = nil
+			}
+			if err != nil {
+				f.Close()
+				return nil, err
+			}
+			return &l, nil
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		if line := strings.TrimSpace(s); line != "" {
+			h := sign.DocumentHash(line)
+			if _, _, err := h.Digest(); err != nil {
+				if _, perr := r.Peek(1); perr == io.EOF {
+					if err := truncate(f, offset); err != nil {
+						f.Close()
+						return nil, err
+					}
+					return &l, nil
+				}
+				f.Close()
+				return nil, fmt.Errorf("record %d: %v", len(l.hashes), err)
+			}
+			l.add(h)
+		}
+		offset += int64(len(s))
+	}
+}
+
+// truncate drops everything after the last complete record at offset
+func truncate(f *os.File, offset int64) error {
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Close closes the file of a persisted log
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func (l *Log) add(h sign.DocumentHash) {
+	n := LeafHash([]byte(h))
+	l.hashes = append(l.hashes, h)
+	l.leaves = append(l.leaves, n)
+
+	// Merge the new leaf with the subtrees of the same size, like a binary counter
+	for size := len(l.leaves); size&1 == 0; size >>= 1 {
+		n = nodeHash(l.peaks[len(l.peaks)-1], n)
+		l.peaks = l.peaks[:len(l.peaks)-1]
+	}
+	l.peaks = append(l.peaks, n)
+}
+
+// root returns the root hash of all records in the log, which is computed from the subtree roots
+func (l *Log) root() []byte {
+	if len(l.peaks) == 0 {
+		return emptyRoot()
+	}
+	r := l.peaks[len(l.peaks)-1]
+	for i := len(l.peaks) - 2; i >= 0; i-- {
+		r = nodeHash(l.peaks[i], r)
+	}
+	return r
+}
+
+// Append adds a document hash to the log and returns its index
+func (l *Log) Append(h sign.DocumentHash) (uint64, error) {
+	if _, _, err := h.Digest(); err != nil {
+		return 0, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		if _, err := l.file.WriteString(string(h) + "\n"); err != nil {
+			return 0, err
+		}
+		if err := l.file.Sync(); err != nil {
+			return 0, err
+		}
+	}
+
+	l.add(h)
+	return uint64(len(l.leaves) - 1), nil
+}
+
+// Size returns the number of records in the log
+func (l *Log) Size() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return uint64(len(l.leaves))
+}
+
+// Get returns the record at index
+func (l *Log) Get(index uint64) (sign.DocumentHash, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if index >= uint64(len(l.hashes)) {
+		return "", ErrInvalidIndex
+	}
+	return l.hashes[index], nil
+}
+
+// Root returns the root hash of the log when it contained size records
+func (l *Log) Root(size uint64) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if size > uint64(len(l.leaves)) {
+		return nil, ErrInvalidSize
+	}
+	if size == uint64(len(l.leaves)) {
+		return l.root(), nil
+	}
+	return rootHash(l.leaves[:size]), nil
+}
+
+// InclusionProof proves that the record at index is part of the log when it contained size records
+type InclusionProof struct {
+	Index  uint64   `json:"index"`
+	Size   uint64   `json:"size"`
+	Hashes []string `json:"hashes"`
+}
+
+// InclusionProof returns the proof that the record at index is part of the log with size records
+func (l *Log) InclusionProof(index, size uint64) (*InclusionProof, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if size > uint64(len(l.leaves)) {
+		return nil, ErrInvalidSize
+	}
+	if index >= size {
+		return nil, ErrInvalidIndex
+	}
+	return &InclusionProof{Index: index, Size: size, Hashes: encode(inclusionPath(index, l.leaves[:size]))}, nil
+}
+
+// Verify checks that the record h is included in the tree described by the tree head
+func (p *InclusionProof) Verify(h sign.DocumentHash, th TreeHead) error {
+	if p.Size != th.Size {
+		return ErrInvalidSize
+	}
+	root, err := hex.DecodeString(th.RootHash)
+	if err != nil {
+		return err
+	}
+	proof, err := decode(p.Hashes)
+	if err != nil {
+		return err
+	}
+	return VerifyInclusion(LeafHash([]byte(h)), p.Index, p.Size, proof, root)
+}
+
+// ConsistencyProof proves that a log with NewSize records only appended records to the log with OldSize records
+type ConsistencyProof struct {
+	OldSize uint64   `json:"old_size"`
+	NewSize uint64   `json:"new_size"`
+	Hashes  []string `json:"hashes"`
+}
+
+// ConsistencyProof returns the proof that the log with newSize records extends the log with oldSize records
+func (l *Log) ConsistencyProof(oldSize, newSize uint64) (*ConsistencyProof, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if oldSize > newSize || newSize > uint64(len(l.leaves)) {
+		return nil, ErrInvalidSize
+	}
+
+	var path [][]byte
+	if oldSize > 0 && oldSize < newSize {
+		path = consistencyPath(oldSize, l.leaves[:newSize], true)
+	}
+	return &ConsistencyProof{OldSize: oldSize, NewSize: newSize, Hashes: encode(path)}, nil
+}
+
+// Verify checks that the tree described by newer is an append-only extension of the tree described by older
+func (p *ConsistencyProof) Verify(older, newer TreeHead) error {
+	if p.OldSize != older.Size || p.NewSize != newer.Size {
+		return ErrInvalidSize
+	}
+	oldRoot, err := hex.DecodeString(older.RootHash)
+	if err != nil {
+		return err
+	}
+	newRoot, err := hex.DecodeString(newer.RootHash)
+	if err != nil {
+		return err
+	}
+	proof, err := decode(p.Hashes)
+	if err != nil {
+		return err
+	}
+	return VerifyConsistency(p.OldSize, p.NewSize, oldRoot, newRoot, proof)
+}
+
+// TreeHead describes the state of the log at a point in time
+type TreeHead struct {
+	Size      uint64    `json:"size"`
+	RootHash  string    `json:"root_hash"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SignedTreeHead is a TreeHead signed by the operator of the log
+type SignedTreeHead struct {
+	TreeHead
+	KeyID     string         `json:"key_id"`
+	Signature sign.Signature `json:"signature"`
+}
+
+// TreeHead returns the current tree head of the log
+func (l *Log) TreeHead() TreeHead {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return TreeHead{
+		Size:      uint64(len(l.leaves)),
+		RootHash:  hex.EncodeToString(l.root()),
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// SignTreeHead returns the current tree head signed with k
+func (l *Log) SignTreeHead(k crypto.Signer) (*SignedTreeHead, error) {
+	id, err := sign.KeyID(k.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	sth := SignedTreeHead{TreeHead: l.TreeHead(), KeyID: id}
+	h, err := sign.HashDocument(sth.TreeHead)
+	if err != nil {
+		return nil, err
+	}
+	if sth.Signature, err = sign.Sign(h, k); err != nil {
+		return nil, err
+	}
+	return &sth, nil
+}
+
+// Verify checks the signature of the tree head with the public key k
+func (sth *SignedTreeHead) Verify(k crypto.PublicKey) error {
+	id, err := sign.KeyID(k)
+	if err != nil {
+		return err
+	}
+	if id != sth.KeyID {
+		return ErrKeyMismatch
+	}
+
+	h, err := sign.HashDocument(sth.TreeHead)
+	if err != nil {
+		return err
+	}
+	return sign.ValidSignature(h, sth.Signature, k)
+}
+
+// ParseSignedTreeHead parses a JSON encoded signed tree head
+func ParseSignedTreeHead(b []byte) (*SignedTreeHead, error) {
+	var sth SignedTreeHead
+	if err := json.Unmarshal(b, &sth); err != nil {
+		return nil, err
+	}
+	return &sth, nil
+}
+
+func encode(path [][]byte) []string {
+	s := make([]string, len(path))
+	for i, p := range path {
+		s[i] = hex.EncodeToString(p)
+	}
+	return s
+}
+
+func decode(s []string) ([][]byte, error) {
+	path := make([][]byte, len(s))
+	for i, v := range s {
+		b, err := hex.DecodeString(v)
+		if err != nil {
+			return nil, ErrInvalidProof
+		}
+		path[i] = b
+	}
+	return path, nil
+}
@@ -0,0 +1,196 @@
+// Package merkle implements a tamper-evident, append-only log of document hashes. The log is a Merkle tree as
+// described in RFC 6962 (Certificate Transparency). Signed tree heads commit to the content of the log, inclusion
+// proofs show that a record is part of the log and consistency proofs show that a newer version of the log only
+// appended records to an older version. All proofs can be verified offline, without access to the log.
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+// Domain separation prefixes for leaf and node hashes, which prevent second preimage attacks
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+var (
+	ErrInvalidIndex = errors.New("index is out of range for the tree size")
+	ErrInvalidSize  = errors.New("invalid tree size")
+	ErrInvalidProof = errors.New("invalid proof")
+	ErrRootMismatch = errors.New("proof doesn't lead to the expected root hash")
+)
+
+// LeafHash returns the hash of a leaf with the provided data
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// nodeHash returns the hash of an interior node
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// emptyRoot is the root hash of a tree without leaves
+func emptyRoot() []byte {
+	h := sha256.Sum256(nil)
+	return h[:]
+}
+
+// split returns the largest power of two smaller than n, for n > 1
+func split(n uint64) uint64 {
+	k := uint64(1)
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// rootHash computes the Merkle tree hash of the leaf hashes
+func rootHash(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		return emptyRoot()
+	case 1:
+		return leaves[0]
+	}
+	k := split(uint64(len(leaves)))
+	return nodeHash(rootHash(leaves[:k]), rootHash(leaves[k:]))
+}
+
+// inclusionPath computes the audit path of leaf m in the tree of the leaf hashes
+func inclusionPath(m uint64, leaves [][]byte) [][]byte {
+	n := uint64(len(leaves))
+	if n <= 1 {
+		return nil
+	}
+	k := split(n)
+	if m < k {
+		return append(inclusionPath(m, leaves[:k]), rootHash(leaves[k:]))
+	}
+	return append(inclusionPath(m-k, leaves[k:]), rootHash(leaves[:k]))
+}
+
+// consistencyPath computes the consistency proof between the tree of the first m leaves and the tree of all leaves
+func consistencyPath(m uint64, leaves [][]byte, complete bool) [][]byte {
+	n := uint64(len(leaves))
+	if m == n {
+		if complete {
+			return nil
+		}
+		return [][]byte{rootHash(leaves)}
+	}
+	k := split(n)
+	if m <= k {
+		return append(consistencyPath(m, leaves[:k], complete), rootHash(leaves[k:]))
+	}
+	return append(consistencyPath(m-k, leaves[k:], false), rootHash(leaves[:k]))
+}
+
+// VerifyInclusion checks that leafHash is the leaf at index in the tree with the provided size and root hash
+func VerifyInclusion(leafHash []byte, index, size uint64, proof [][]byte, root []byte) error {
+	if index >= size {
+		return ErrInvalidIndex
+	}
+
+	fn, sn := index, size-1
+	r := leafHash
+	for _, p := range proof {
+		if sn == 0 {
+			return ErrInvalidProof
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 {
+		return ErrInvalidProof
+	}
+	if !bytes.Equal(r, root) {
+		return ErrRootMismatch
+	}
+	return nil
+}
+
+// VerifyConsistency checks that the tree with newSize leaves and root newRoot is an append-only extension of the
+// tree with oldSize leaves and root oldRoot
+func VerifyConsistency(oldSize, newSize uint64, oldRoot, newRoot []byte, proof [][]byte) error {
+	switch {
+	case oldSize > newSize:
+		return ErrInvalidSize
+	case oldSize == newSize:
+		if len(proof) != 0 {
+			return ErrInvalidProof
+		}
+		if !bytes.Equal(oldRoot, newRoot) {
+			return ErrRootMismatch
+		}
+		return nil
+	case oldSize == 0:
+		// The empty tree is consistent with every tree
+		if len(proof) != 0 {
+			return ErrInvalidProof
+		}
+		return nil
+	}
+
+	if len(proof) == 0 {
+		return ErrInvalidProof
+	}
+
+	// When the old tree is a complete subtree its root is the start of the path
+	if oldSize&(oldSize-1) == 0 {
+		proof = append([][]byte{oldRoot}, proof...)
+	}
+
+	fn, sn := oldSize-1, newSize-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return ErrInvalidProof
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = nodeHash(c, fr)
+			sr = nodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = nodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 {
+		return ErrInvalidProof
+	}
+	if !bytes.Equal(fr, oldRoot) || !bytes.Equal(sr, newRoot) {
+		return ErrRootMismatch
+	}
+	return nil
+}
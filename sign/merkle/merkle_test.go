@@ -0,0 +1,211 @@
+package merkle
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arjanvaneersel/kit/sign"
+)
+
+func record(t *testing.T, i int) sign.DocumentHash {
+	h, err := sign.HashDocument(map[string]int{"record": i})
+	if err != nil {
+		t.Fatalf("expected HashDocument to pass, but got %v", err)
+	}
+	return h
+}
+
+func TestRFC6962Root(t *testing.T) {
+	// Test vectors from the Certificate Transparency reference implementation
+	leaves := []string{"", "00", "10", "2021", "3031", "40414243", "5051525354555657", "606162636465666768696a6b6c6d6e6f"}
+	roots := []string{
+		"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+		"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+		"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+		"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+	}
+
+	var hashes [][]byte
+	for i, leaf := range leaves {
+		b, _ := hex.DecodeString(leaf)
+		hashes = append(hashes, LeafHash(b))
+		if got := hex.EncodeToString(rootHash(hashes)); got != roots[i] {
+			t.Errorf("size %d: expected root %s, but got %s", i+1, roots[i], got)
+		}
+	}
+	if got := hex.EncodeToString(rootHash(nil)); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("unexpected empty root %s", got)
+	}
+}
+
+func TestProofs(t *testing.T) {
+	l := NewLog()
+	var heads []TreeHead
+	for i := 0; i < 20; i++ {
+		heads = append(heads, l.TreeHead())
+		if _, err := l.Append(record(t, i)); err != nil {
+			t.Fatalf("expected Append to pass, but got %v", err)
+		}
+	}
+	heads = append(heads, l.TreeHead())
+
+	for size := uint64(1); size <= l.Size(); size++ {
+		for i := uint64(0); i < size; i++ {
+			p, err := l.InclusionProof(i, size)
+			if err != nil {
+				t.Fatalf("expected InclusionProof(%d, %d) to pass, but got %v", i, size, err)
+			}
+			h, _ := l.Get(i)
+			if err := p.Verify(h, heads[size]); err != nil {
+				t.Errorf("inclusion %d in %d: expected to pass, but got %v", i, size, err)
+			}
+			if err := p.Verify(record(t, 100), heads[size]); err == nil {
+				t.Errorf("inclusion %d in %d: expected a foreign record to fail", i, size)
+			}
+		}
+
+		for old := uint64(0); old <= size; old++ {
+			p, err := l.ConsistencyProof(old, size)
+			if err != nil {
+				t.Fatalf("expected ConsistencyProof(%d, %d) to pass, but got %v", old, size, err)
+			}
+			if err := p.Verify(heads[old], heads[size]); err != nil {
+				t.Errorf("consistency %d to %d: expected to pass, but got %v", old, size, err)
+			}
+			if old > 0 && old < size {
+				forged := heads[old]
+				forged.RootHash = heads[old-1].RootHash
+				if err := p.Verify(forged, heads[size]); err == nil {
+					t.Errorf("consistency %d to %d: expected a forged root to fail", old, size)
+				}
+			}
+		}
+	}
+}
+
+func TestSignedTreeHead(t *testing.T) {
+	k, err := sign.GenerateKey(sign.Ed25519)
+	if err != nil {
+		t.Fatalf("expected GenerateKey to pass, but got %v", err)
+	}
+
+	l := NewLog()
+	l.Append(record(t, 1))
+
+	sth, err := l.SignTreeHead(k)
+	if err != nil {
+		t.Fatalf("expected SignTreeHead to pass, but got %v", err)
+	}
+	if err := sth.Verify(k.Public()); err != nil {
+		t.Errorf("expected Verify to pass, but got %v", err)
+	}
+
+	sth.Size++
+	if err := sth.Verify(k.Public()); err == nil {
+		t.Errorf("expected a modified tree head to fail")
+	}
+}
+
+func TestOpenLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	l, err := OpenLog(path)
+	if err != nil {
+		t.Fatalf("expected OpenLog to pass, but got %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := l.Append(record(t, i)); err != nil {
+			t.Fatalf("expected Append to pass, but got %v", err)
+		}
+	}
+	before := l.TreeHead()
+	l.Close()
+
+	l, err = OpenLog(path)
+	if err != nil {
+		t.Fatalf("expected OpenLog to pass, but got %v", err)
+	}
+	defer l.Close()
+
+	after := l.TreeHead()
+	if after.Size != 5 || after.RootHash != before.RootHash {
+		t.Errorf("expected the reopened log to have the same root, but got %+v and %+v", before, after)
+	}
+	if _, err := l.Append(sign.DocumentHash(fmt.Sprintf("v1:%x", "short"))); err == nil {
+		t.Errorf("expected an invalid hash to be refused")
+	}
+}
+
+func TestCachedRoot(t *testing.T) {
+	l := NewLog()
+	for i := 0; i < 33; i++ {
+		if got, want := l.root(), rootHash(l.leaves); hex.EncodeToString(got) != hex.EncodeToString(want) {
+			t.Errorf("size %d: expected root %x, but got %x", i, want, got)
+		}
+		if _, err := l.Append(record(t, i)); err != nil {
+			t.Fatalf("expected Append to pass, but got %v", err)
+		}
+	}
+}
+
+func TestOpenTruncatedLog(t *testing.T) {
+	for name, tail := range map[string]string{
+		"partial line":  "v1:0123",
+		"invalid line":  "v1:0123\n",
+		"missing hex":   "v1:",
+		"empty trailer": "\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			var fixture string
+			for i := 0; i < 3; i++ {
+				fixture += string(record(t, i)) + "\n"
+			}
+			if err := os.WriteFile(path, []byte(fixture+tail), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			l, err := OpenLog(path)
+			if err != nil {
+				t.Fatalf("expected OpenLog to pass, but got %v", err)
+			}
+			if l.Size() != 3 {
+				t.Errorf("expected 3 records, but got %d", l.Size())
+			}
+			if _, err := l.Append(record(t, 3)); err != nil {
+				t.Fatalf("expected Append to pass, but got %v", err)
+			}
+			l.Close()
+
+			l, err = OpenLog(path)
+			if err != nil {
+				t.Fatalf("expected OpenLog to pass, but got %v", err)
+			}
+			defer l.Close()
+			if l.Size() != 4 {
+				t.Errorf("expected 4 records, but got %d", l.Size())
+			}
+			if h, _ := l.Get(3); h != record(t, 3) {
+				t.Errorf("expected record %s, but got %s", record(t, 3), h)
+			}
+		})
+	}
+}
+
+func TestOpenCorruptLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	fixture := string(record(t, 0)) + "\nv1:0123\n" + string(record(t, 1)) + "\n"
+	if err := os.WriteFile(path, []byte(fixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenLog(path); err == nil {
+		t.Errorf("expected a corrupt record before the end of the log to fail")
+	}
+}
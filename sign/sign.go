@@ -1,14 +1,14 @@
 package sign
 
 import (
-	"bytes"
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
-	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 )
 
 // DocumentHash represents the hashed value of a document
@@ -20,9 +20,18 @@ type Signature string
 // The types DocumentHash and Signature are implemented as separate types to prevent accidental mixing up of these values
 // which was possible when the functions creating them simply returned strings instead of these custom stirng based types
 
-// CreateKeyPair creates and returns a private key, public key
+// CreateKeyPair creates and returns a private key, public key. The RSA key has a size of DefaultRSAKeySize.
 func CreateKeyPair() (*rsa.PrivateKey, *rsa.PublicKey, error) {
-	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	return CreateRSAKeyPair(DefaultRSAKeySize)
+}
+
+// CreateRSAKeyPair creates and returns a private key, public key with the provided size in bits
+func CreateRSAKeyPair(bits int) (*rsa.PrivateKey, *rsa.PublicKey, error) {
+	if bits < MinRSAKeySize {
+		return nil, nil, fmt.Errorf("RSA keys must be at least %d bits", MinRSAKeySize)
+	}
+
+	privKey, err := rsa.GenerateKey(rand.Reader, bits)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -36,44 +45,108 @@ func CreateKeyPair() (*rsa.PrivateKey, *rsa.PublicKey, error) {
 	return privKey, &pubKey, nil
 }
 
-// HashDocument creates and returns the DocumentHash of the provided document (d)
+// HashFormatV1 is the prefix of document hashes created from the canonical JSON encoding of a document.
+// Hashes without a prefix were created by earlier versions of this package, which didn't hash most documents
+// correctly, and can only be used to verify existing signatures.
+const HashFormatV1 = "v1"
+
+// ErrInvalidHash is returned when a DocumentHash can't be parsed
+var ErrInvalidHash = errors.New("invalid document hash")
+
+// HashDocument creates and returns the DocumentHash of the provided document (d). The document is hashed with
+// SHA-256 over its canonical JSON encoding (see Canonicalize) and the hash is prefixed with its format version,
+// i.e. "v1:9f86d0...". An error is returned for documents which can't be encoded.
 func HashDocument(d interface{}) (DocumentHash, error) {
-	var buf = bytes.Buffer{}
-	binary.Write(&buf, binary.BigEndian, d)
-	return DocumentHash(fmt.Sprintf("%x", sha256.Sum256(buf.Bytes()))), nil
+	b, err := Canonicalize(d)
+	if err != nil {
+		return "", err
+	}
+	return DocumentHash(fmt.Sprintf("%s:%x", HashFormatV1, sha256.Sum256(b))), nil
+}
+
+// Format returns the format version of the hash, which is empty for hashes created before versioning
+func (h DocumentHash) Format() string {
+	if i := strings.IndexByte(string(h), ':'); i >= 0 {
+		return string(h[:i])
+	}
+	return ""
+}
+
+// Digest returns the raw hash value and the hash function which was used to create it
+func (h DocumentHash) Digest() ([]byte, crypto.Hash, error) {
+	f, v := h.Format(), string(h)
+	if f != "" {
+		v = v[len(f)+1:]
+	}
+
+	var hf crypto.Hash
+	switch f {
+	case "", HashFormatV1, HashFormatSHA256:
+		hf = crypto.SHA256
+	case HashFormatSHA512:
+		hf = crypto.SHA512
+	default:
+		return nil, 0, ErrInvalidHash
+	}
+
+	b, err := hex.DecodeString(v)
+	if err != nil || len(b) != hf.Size() {
+		return nil, 0, ErrInvalidHash
+	}
+	return b, hf, nil
+}
+
+// Algorithm returns the algorithm of the signature. Signatures without an algorithm prefix were created by
+// earlier versions of this package and use RSA PKCS #1 v1.5.
+func (s Signature) Algorithm() Algorithm {
+	if i := strings.IndexByte(string(s), ':'); i >= 0 {
+		return Algorithm(s[:i])
+	}
+	return RSAPKCS1v15
 }
 
-// Sign creates and returns a Signature based upon a document hash (h) and a private key (k)
-func Sign(h DocumentHash, k *rsa.PrivateKey) (Signature, error) {
-	hash, err := hex.DecodeString(string(h))
+// Decode returns the algorithm and the raw value of the signature
+func (s Signature) Decode() (Algorithm, []byte, error) {
+	v := string(s)
+	if i := strings.IndexByte(v, ':'); i >= 0 {
+		v = v[i+1:]
+	}
+
+	b, err := hex.DecodeString(v)
 	if err != nil {
-		return "", err
+		return "", nil, ErrInvalidSignature
 	}
+	return s.Algorithm(), b, nil
+}
 
-	sig, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, hash[:])
+// Sign creates and returns a Signature based upon a document hash (h) and a private key (k). The signature
+// is prefixed with its algorithm, i.e. "Ed25519:8f3a...". Keys created with NewSigner or GenerateKey use their
+// algorithm, other keys use the DefaultAlgorithm of their type.
+func Sign(h DocumentHash, k crypto.Signer) (Signature, error) {
+	hash, hf, err := h.Digest()
 	if err != nil {
 		return "", err
 	}
-	return Signature(fmt.Sprintf("%x", sig)), nil
-}
 
-// ValidSignature checks if the provided public key (k) is valid for the signature (s) of a document's hash (h)
-func ValidSignature(h DocumentHash, s Signature, k *rsa.PublicKey) error {
-	hash, err := hex.DecodeString(string(h))
+	s, err := signer(k)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	sig, err := hex.DecodeString(string(s))
+	sig, err := s.Sign(rand.Reader, hash, hf)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return Signature(fmt.Sprintf("%s:%x", s.Algorithm(), sig)), nil
+}
 
-	err = rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], sig)
+// ValidSignature checks if the provided public key (k) is valid for the signature (s) of a document's hash (h)
+func ValidSignature(h DocumentHash, s Signature, k crypto.PublicKey) error {
+	v, err := NewVerifier(k)
 	if err != nil {
 		return err
 	}
-	return nil
+	return v.Verify(h, s)
 }
 
 // Todo: Examples
@@ -0,0 +1,183 @@
+package sign
+
+import (
+	"crypto"
+	_ "crypto/sha512" // registers SHA-512 for crypto.Hash.New
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// Prefixes of hashes created by HashReader and HashFile
+const (
+	HashFormatSHA256 = "sha256"
+	HashFormatSHA512 = "sha512"
+)
+
+// SignatureExt is the extension of detached signature files
+const SignatureExt = ".sig"
+
+var (
+	ErrUnsupportedHash = errors.New("unsupported hash function, use SHA-256 or SHA-512")
+	ErrKeyMismatch     = errors.New("signature was created with a different key")
+	ErrHashMismatch    = errors.New("file doesn't match the signed hash")
+)
+
+// HashReader streams r through the provided hash function, which must be crypto.SHA256 or crypto.SHA512, and
+// returns the hash prefixed with the name of the hash function, i.e. "sha512:cf83e1...". Memory usage is
+// constant, regardless of the size of the input.
+func HashReader(r io.Reader, hf crypto.Hash) (DocumentHash, error) {
+	var prefix string
+	switch hf {
+	case crypto.SHA256:
+		prefix = HashFormatSHA256
+	case crypto.SHA512:
+		prefix = HashFormatSHA512
+	default:
+		return "", ErrUnsupportedHash
+	}
+
+	h := hf.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return DocumentHash(fmt.Sprintf("%s:%x", prefix, h.Sum(nil))), nil
+}
+
+// HashFile returns the hash of the file at path, see HashReader
+func HashFile(path string, hf crypto.Hash) (DocumentHash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return HashReader(f, hf)
+}
+
+// DetachedSignature is the content of a .sig file, which is stored next to the file it signs
+type DetachedSignature struct {
+	Algorithm string       `json:"algorithm"`
+	KeyID     string       `json:"key_id"`
+	Hash      DocumentHash `json:"hash"`
+	Signature Signature    `json:"signature"`
+}
+
+// SignReader hashes r with the provided hash function and signs the hash with k
+func SignReader(r io.Reader, k crypto.Signer, hf crypto.Hash) (*DetachedSignature, error) {
+	h, err := HashReader(r, hf)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := KeyID(k.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := Sign(h, k)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DetachedSignature{
+		Algorithm: string(sig.Algorithm()),
+		KeyID:     id,
+		Hash:      h,
+		Signature: sig,
+	}, nil
+}
+
+// VerifyReader checks if the content of r matches the detached signature and if the signature is valid for k
+func VerifyReader(r io.Reader, ds *DetachedSignature, k crypto.PublicKey) error {
+	id, err := KeyID(k)
+	if err != nil {
+		return err
+	}
+	if ds.KeyID != "" && ds.KeyID != id {
+		return ErrKeyMismatch
+	}
+
+	_, hf, err := ds.Hash.Digest()
+	if err != nil {
+		return err
+	}
+	if ds.Algorithm != string(ds.Signature.Algorithm()) {
+		return fmt.Errorf("%w: algorithm %s doesn't match the signature", ErrAlgorithmMismatch, ds.Algorithm)
+	}
+
+	h, err := HashReader(r, hf)
+	if err != nil {
+		return err
+	}
+	if h != ds.Hash {
+		return ErrHashMismatch
+	}
+
+	return ValidSignature(h, ds.Signature, k)
+}
+
+// SignFile signs the file at path and writes the detached signature to path.sig
+func SignFile(path string, k crypto.Signer, hf crypto.Hash) (*DetachedSignature, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ds, err := SignReader(f, k, hf)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := WriteSignatureFile(path+SignatureExt, ds); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// VerifyFile checks the file at path against the detached signature in path.sig. From the command line the
+// signature is checked with `kitsign verify -pub key.pem.pub FILE`.
+func VerifyFile(path string, k crypto.PublicKey) error {
+	ds, err := ReadSignatureFile(path + SignatureExt)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return VerifyReader(f, ds, k)
+}
+
+// ReadSignatureFile reads a detached signature from the file at path
+func ReadSignatureFile(path string) (*DetachedSignature, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ds DetachedSignature
+	if err := json.Unmarshal(b, &ds); err != nil {
+		return nil, err
+	}
+	if _, _, err := ds.Signature.Decode(); err != nil {
+		return nil, err
+	}
+	return &ds, nil
+}
+
+// WriteSignatureFile writes a detached signature to the file at path
+func WriteSignatureFile(path string, ds *DetachedSignature) error {
+	b, err := json.MarshalIndent(ds, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
@@ -0,0 +1,85 @@
+package sign_test
+
+import (
+	"crypto"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arjanvaneersel/kit/sign"
+)
+
+func TestHashReader(t *testing.T) {
+	tests := []struct {
+		hf       crypto.Hash
+		expected sign.DocumentHash
+	}{
+		{crypto.SHA256, "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{crypto.SHA512, "sha512:cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e"},
+	}
+
+	for i, tc := range tests {
+		h, err := sign.HashReader(strings.NewReader(""), tc.hf)
+		if err != nil {
+			t.Fatalf("[FAIL] Test %d: Expected HashReader to pass, but got error %q.", i+1, err)
+		}
+		if h != tc.expected {
+			t.Errorf("[FAIL] Test %d: Expected %s, but got %s.", i+1, tc.expected, h)
+		}
+	}
+
+	if _, err := sign.HashReader(strings.NewReader(""), crypto.MD5); err != sign.ErrUnsupportedHash {
+		t.Errorf("[FAIL] Expected %q, but got %q.", sign.ErrUnsupportedHash, err)
+	}
+}
+
+func TestSignFile(t *testing.T) {
+	priv, pub, err := sign.CreateKeyPair()
+	if err != nil {
+		t.Fatalf("[FAIL] Expected CreateKeyPair to pass, but got error %q.", err)
+	}
+	_, otherPub, err := sign.CreateKeyPair()
+	if err != nil {
+		t.Fatalf("[FAIL] Expected CreateKeyPair to pass, but got error %q.", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "release.tar.gz")
+	if err := ioutil.WriteFile(path, []byte("release artifact"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hf := range []crypto.Hash{crypto.SHA256, crypto.SHA512} {
+		ds, err := sign.SignFile(path, priv, hf)
+		if err != nil {
+			t.Fatalf("[FAIL] Expected SignFile to pass, but got error %q.", err)
+		}
+		if ds.KeyID == "" || ds.Algorithm == "" {
+			t.Errorf("[FAIL] Expected the signature to record key id and algorithm, but got %+v.", ds)
+		}
+
+		if err := sign.VerifyFile(path, pub); err != nil {
+			t.Errorf("[FAIL] Expected VerifyFile to pass, but got error %q.", err)
+		}
+		if err := sign.VerifyFile(path, otherPub); err != sign.ErrKeyMismatch {
+			t.Errorf("[FAIL] Expected %q, but got %q.", sign.ErrKeyMismatch, err)
+		}
+	}
+
+	ds, err := sign.ReadSignatureFile(path + sign.SignatureExt)
+	if err != nil {
+		t.Fatalf("[FAIL] Expected ReadSignatureFile to pass, but got error %q.", err)
+	}
+	ds.Algorithm = string(sign.RSAPSS)
+	if err := sign.VerifyReader(strings.NewReader("release artifact"), ds, pub); !errors.Is(err, sign.ErrAlgorithmMismatch) {
+		t.Errorf("[FAIL] Expected %q for a tampered algorithm, but got %q.", sign.ErrAlgorithmMismatch, err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("tampered artifact"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := sign.VerifyFile(path, pub); err != sign.ErrHashMismatch {
+		t.Errorf("[FAIL] Expected %q, but got %q.", sign.ErrHashMismatch, err)
+	}
+}